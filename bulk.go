@@ -0,0 +1,227 @@
+package greip
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+)
+
+const (
+	// ? Default number of IP addresses sent in a single BulkLookup request
+	defaultBulkChunkSize = 20
+	// ? Default number of BulkLookup requests sent in parallel
+	defaultBulkConcurrency = 4
+)
+
+// WithBulkChunkSize sets the maximum number of IP addresses sent in a single BulkLookup request.
+func WithBulkChunkSize(size int) Option {
+	return func(g *Greip) {
+		g.bulkChunkSize = size
+	}
+}
+
+// WithBulkConcurrency sets the maximum number of BulkLookup requests sent in parallel
+// when the IP addresses don't fit in a single chunk.
+func WithBulkConcurrency(n int) Option {
+	return func(g *Greip) {
+		g.bulkConcurrency = n
+	}
+}
+
+// BulkLookupError is returned by BulkLookup when some of the IP addresses could not be looked up.
+// The results of the other IP addresses are returned along with it.
+type BulkLookupError struct {
+	// Errors maps every IP address that could not be looked up to the reason why.
+	Errors map[string]error
+	// Total is the number of IP addresses that were requested.
+	Total int
+}
+
+// Error reports the number of failed IP addresses along with the error of the smallest one,
+// so that the message is the same from one run to the next.
+func (e *BulkLookupError) Error() string {
+	ips := e.sortedIPs()
+	if len(ips) == 0 {
+		return "bulk lookup failed"
+	}
+	return fmt.Sprintf("bulk lookup failed for %d of %d IP addresses: %s: %v", len(e.Errors), e.Total, ips[0], e.Errors[ips[0]])
+}
+
+// Unwrap returns the distinct errors that caused the failure, ordered by IP address, so that
+// errors.Is and errors.As can be used to inspect them.
+func (e *BulkLookupError) Unwrap() []error {
+	var errs []error
+	seen := make(map[error]bool)
+	for _, ip := range e.sortedIPs() {
+		if err := e.Errors[ip]; !seen[err] {
+			seen[err] = true
+			errs = append(errs, err)
+		}
+	}
+	return errs
+}
+
+// ? Helper function to get the failed IP addresses in order
+func (e *BulkLookupError) sortedIPs() []string {
+	ips := make([]string, 0, len(e.Errors))
+	for ip := range e.Errors {
+		ips = append(ips, ip)
+	}
+	sort.Strings(ips)
+	return ips
+}
+
+// ? Helper function to look up the IP addresses chunk by chunk with bounded parallelism,
+// merging the results and collecting the errors of the failed chunks. Results and errors
+// are keyed by the IP addresses as given, while the API receives their normalised form.
+func (g *Greip) bulkLookup(ctx context.Context, ips []string, params []string, lang string) (*map[string]ResponseLookup, error) {
+	results := make(map[string]ResponseLookup, len(ips))
+	failures := make(map[string]error)
+
+	//? Normalise the IPs and settle the invalid and non-routable ones without any request
+	origins := make(map[string][]string, len(ips))
+	var normalized []string
+	for _, ip := range ips {
+		addr, err := normalizeIP("ips", ip)
+		if err != nil {
+			failures[ip] = err
+			continue
+		}
+		if handled, err := g.checkRoutable(addr); handled {
+			if err != nil {
+				failures[ip] = err
+			} else {
+				results[ip] = privateNetworkLookup(addr)
+			}
+			continue
+		}
+		key := addr.String()
+		if _, ok := origins[key]; !ok {
+			normalized = append(normalized, key)
+		}
+		origins[key] = append(origins[key], ip)
+	}
+
+	//? Copy the results of a chunk to every IP address that normalises to the same one
+	merge := func(response map[string]ResponseLookup) {
+		for ip, result := range response {
+			if inputs, ok := origins[ip]; ok {
+				for _, input := range inputs {
+					results[input] = result
+				}
+			} else {
+				results[ip] = result
+			}
+		}
+	}
+
+	chunks := chunkStrings(normalized, g.bulkChunkSizeOrDefault())
+	switch {
+	case len(normalized) == 0 && len(ips) > 0:
+		//? Nothing left to send
+	case len(chunks) == 1 && len(failures) == 0:
+		//? A single chunk behaves exactly like a plain request
+		response, err := g.bulkLookupChunk(ctx, chunks[0], params, lang)
+		if err != nil {
+			return nil, err
+		}
+		merge(*response)
+	default:
+		var mu sync.Mutex
+		var wg sync.WaitGroup
+		queue := make(chan []string)
+
+		//? A fixed pool of workers, so that a large input doesn't start one goroutine per chunk
+		workers := min(g.bulkConcurrencyOrDefault(), len(chunks))
+		for i := 0; i < workers; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for chunk := range queue {
+					//? Chunks left once the context is done fail without being sent
+					err := ctx.Err()
+					var response *map[string]ResponseLookup
+					if err == nil {
+						response, err = g.bulkLookupChunk(ctx, chunk, params, lang)
+					}
+
+					mu.Lock()
+					if err != nil {
+						for _, ip := range chunk {
+							for _, input := range origins[ip] {
+								failures[input] = err
+							}
+						}
+					} else {
+						merge(*response)
+					}
+					mu.Unlock()
+				}
+			}()
+		}
+		for _, chunk := range chunks {
+			queue <- chunk
+		}
+		close(queue)
+		wg.Wait()
+	}
+
+	if len(failures) == 0 {
+		return &results, nil
+	}
+	bulkErr := &BulkLookupError{Errors: failures, Total: len(ips)}
+	if len(results) == 0 {
+		return nil, bulkErr
+	}
+	return &results, bulkErr
+}
+
+// ? Helper function to look up a single chunk of IP addresses
+func (g *Greip) bulkLookupChunk(ctx context.Context, ips []string, params []string, lang string) (*map[string]ResponseLookup, error) {
+	payload := map[string]interface{}{
+		"ips":    strings.Join(ips, ","),
+		"params": strings.Join(params, ","),
+		"lang":   lang,
+	}
+
+	response, meta, err := do[map[string]ResponseLookup](ctx, g, http.MethodGet, "BulkLookup", payload)
+	if err != nil {
+		return nil, err
+	}
+
+	//? Every result carries the metadata of the chunk it was returned in
+	for ip, result := range response {
+		result.Meta = meta
+		response[ip] = result
+	}
+	return &response, nil
+}
+
+func (g *Greip) bulkChunkSizeOrDefault() int {
+	if g.bulkChunkSize > 0 {
+		return g.bulkChunkSize
+	}
+	return defaultBulkChunkSize
+}
+
+func (g *Greip) bulkConcurrencyOrDefault() int {
+	if g.bulkConcurrency > 0 {
+		return g.bulkConcurrency
+	}
+	return defaultBulkConcurrency
+}
+
+// ? Helper function to split a slice into chunks of at most size elements; an empty slice yields a single empty chunk
+func chunkStrings(values []string, size int) [][]string {
+	if len(values) <= size {
+		return [][]string{values}
+	}
+	var chunks [][]string
+	for start := 0; start < len(values); start += size {
+		chunks = append(chunks, values[start:min(start+size, len(values))])
+	}
+	return chunks
+}
@@ -0,0 +1,158 @@
+package greip_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	greip "github.com/greipio/go"
+	"github.com/greipio/go/greiptest"
+)
+
+func TestBulkLookupPartialFailure(t *testing.T) {
+	server := greiptest.NewServer()
+	defer server.Close()
+	server.FailKey("BulkLookup", "1.1.1.3,1.1.1.4", greiptest.Failure{
+		StatusCode:  http.StatusInternalServerError,
+		Description: "chunk failed",
+	})
+	client := server.Client(greip.WithBulkChunkSize(2))
+
+	ips := []string{"1.1.1.1", "1.1.1.2", "1.1.1.3", "1.1.1.4", "1.1.1.5"}
+	response, err := client.BulkLookup(ips, nil)
+
+	if got := len(server.RequestsTo("BulkLookup")); got != 3 {
+		t.Fatalf("got %d requests, want 3 chunks", got)
+	}
+
+	var bulkErr *greip.BulkLookupError
+	if !errors.As(err, &bulkErr) {
+		t.Fatalf("got error %v, want a *BulkLookupError", err)
+	}
+	if bulkErr.Total != 5 || len(bulkErr.Errors) != 2 || bulkErr.Errors["1.1.1.3"] == nil || bulkErr.Errors["1.1.1.4"] == nil {
+		t.Fatalf("got %+v, want errors for 1.1.1.3 and 1.1.1.4 out of 5", bulkErr)
+	}
+	var apiErr *greip.APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusInternalServerError {
+		t.Fatalf("got error %v, want the *APIError of the chunk to be unwrapped", err)
+	}
+
+	//? The message is deterministic: it reports the smallest failed IP address
+	want := "bulk lookup failed for 2 of 5 IP addresses: 1.1.1.3: API error: chunk failed"
+	for i := 0; i < 10; i++ {
+		if got := err.Error(); got != want {
+			t.Fatalf("got message %q, want %q", got, want)
+		}
+	}
+
+	if response == nil || len(*response) != 3 {
+		t.Fatalf("got %v, want the results of the 3 other IP addresses", response)
+	}
+	for _, ip := range []string{"1.1.1.1", "1.1.1.2", "1.1.1.5"} {
+		if (*response)[ip].IP != ip {
+			t.Fatalf("missing result for %s", ip)
+		}
+	}
+}
+
+func TestBulkLookupSingleChunk(t *testing.T) {
+	server := greiptest.NewServer()
+	defer server.Close()
+	client := server.Client(greip.WithBulkChunkSize(2))
+
+	response, err := client.BulkLookup([]string{"1.1.1.1", "1.1.1.2"}, nil)
+	if err != nil || len(*response) != 2 {
+		t.Fatalf("got %v, %v, want 2 results", response, err)
+	}
+
+	//? A single chunk fails like a plain request, with a bare error
+	server.Fail("BulkLookup", greiptest.Failure{StatusCode: http.StatusUnauthorized, Description: "Invalid API key."})
+	response, err = client.BulkLookup([]string{"1.1.1.1", "1.1.1.2"}, nil)
+	var bulkErr *greip.BulkLookupError
+	if response != nil || errors.As(err, &bulkErr) || !errors.Is(err, greip.ErrUnauthorized) {
+		t.Fatalf("got %v, %v, want a bare ErrUnauthorized", response, err)
+	}
+	if got := len(server.RequestsTo("BulkLookup")); got != 2 {
+		t.Fatalf("got %d requests, want 2", got)
+	}
+}
+
+func TestBulkLookupConcurrency(t *testing.T) {
+	server := greiptest.NewServer()
+	defer server.Close()
+	server.SetLatency("BulkLookup", 5*time.Millisecond)
+
+	var mu sync.Mutex
+	inFlight, peak := 0, 0
+	client := server.Client(
+		greip.WithBulkChunkSize(1),
+		greip.WithBulkConcurrency(3),
+		greip.WithMiddleware(func(next greip.RoundTripFunc) greip.RoundTripFunc {
+			return func(call *greip.Call) (*http.Response, error) {
+				mu.Lock()
+				inFlight++
+				peak = max(peak, inFlight)
+				mu.Unlock()
+				defer func() {
+					mu.Lock()
+					inFlight--
+					mu.Unlock()
+				}()
+				return next(call)
+			}
+		}),
+	)
+
+	ips := make([]string, 30)
+	for i := range ips {
+		ips[i] = fmt.Sprintf("1.1.1.%d", i+1)
+	}
+	response, err := client.BulkLookup(ips, nil)
+	if err != nil || len(*response) != len(ips) {
+		t.Fatalf("got %v, %v, want %d results", response, err, len(ips))
+	}
+	if peak > 3 {
+		t.Fatalf("got %d chunks in flight, want at most 3", peak)
+	}
+}
+
+func TestBulkLookupCanceled(t *testing.T) {
+	server := greiptest.NewServer()
+	defer server.Close()
+	client := server.Client(greip.WithBulkChunkSize(1))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := client.BulkLookupContext(ctx, []string{"1.1.1.1", "1.1.1.2", "1.1.1.3"}, nil)
+
+	var bulkErr *greip.BulkLookupError
+	if !errors.As(err, &bulkErr) || len(bulkErr.Errors) != 3 || !errors.Is(err, context.Canceled) {
+		t.Fatalf("got %v, want every chunk to fail with context.Canceled", err)
+	}
+	if got := len(server.RequestsTo("BulkLookup")); got != 0 {
+		t.Fatalf("got %d requests, want none", got)
+	}
+}
+
+func BenchmarkBulkLookup(b *testing.B) {
+	server := greiptest.NewServer()
+	defer server.Close()
+	client := server.Client()
+
+	ips := make([]string, 20)
+	for i := range ips {
+		ips[i] = fmt.Sprintf("1.1.1.%d", i+1)
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := client.BulkLookup(ips, []string{"security"}); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,166 @@
+package greip
+
+import (
+	"container/list"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Cache stores raw API responses. Implementations must be safe for concurrent use.
+type Cache interface {
+	// Get returns the value stored under key, if any and not expired.
+	Get(key string) ([]byte, bool)
+	// Set stores value under key for the given duration.
+	Set(key string, value []byte, ttl time.Duration)
+}
+
+// DefaultCacheTTLs are the durations for which responses are cached once WithCache is used.
+// Endpoints missing from the map are never cached.
+var DefaultCacheTTLs = map[string]time.Duration{
+	"IPLookup":  time.Hour,
+	"threats":   15 * time.Minute,
+	"ASNLookup": 24 * time.Hour,
+	"Country":   24 * time.Hour,
+}
+
+// WithCache caches the responses of Lookup, Threats, AsnLookup and Country in cache,
+// using DefaultCacheTTLs unless overridden with WithCacheTTL.
+func WithCache(cache Cache) Option {
+	return func(g *Greip) {
+		g.cache = cache
+	}
+}
+
+// WithCacheTTL sets how long the responses of the given endpoint (e.g. "IPLookup") are cached.
+// A zero ttl disables caching for the endpoint.
+func WithCacheTTL(endpoint string, ttl time.Duration) Option {
+	return func(g *Greip) {
+		if g.cacheTTLs == nil {
+			g.cacheTTLs = make(map[string]time.Duration)
+		}
+		g.cacheTTLs[endpoint] = ttl
+	}
+}
+
+// ? Helper function to get how long the responses of an endpoint are cached, zero meaning not at all
+func (g *Greip) cacheTTL(endpoint string) time.Duration {
+	if g.cache == nil {
+		return 0
+	}
+	if ttl, ok := g.cacheTTLs[endpoint]; ok {
+		return ttl
+	}
+	return DefaultCacheTTLs[endpoint]
+}
+
+// ? Helper function to build the cache key of a request. The query carries the `mode` parameter,
+// but the mode is also spelled out so that test data can never be served to live calls.
+func cacheKey(test bool, endpoint string, query url.Values) string {
+	mode := "live"
+	if test {
+		mode = "test"
+	}
+
+	//? The order of the requested params doesn't change the response
+	normalized := make(url.Values, len(query))
+	for key, values := range query {
+		normalized[key] = values
+		if key == "params" && len(values) == 1 {
+			params := strings.Split(values[0], ",")
+			sort.Strings(params)
+			normalized[key] = []string{strings.Join(params, ",")}
+		}
+	}
+
+	return mode + ":" + endpoint + "?" + normalized.Encode()
+}
+
+// ? cacheMarker is implemented by the response types that can be served from the cache
+type cacheMarker interface {
+	markFromCache()
+}
+
+func (r *ResponseLookup) markFromCache()  { r.FromCache = true }
+func (r *ResponseThreats) markFromCache() { r.FromCache = true }
+func (r *ResponseCountry) markFromCache() { r.FromCache = true }
+func (r *ResponseASN) markFromCache()     { r.FromCache = true }
+
+// LRUCache is an in-memory Cache holding a bounded number of entries,
+// evicting the least recently used one when full.
+type LRUCache struct {
+	mu         sync.Mutex
+	maxEntries int
+	entries    map[string]*list.Element
+	order      *list.List
+}
+
+type lruEntry struct {
+	key       string
+	value     []byte
+	expiresAt time.Time
+}
+
+// NewLRUCache creates an LRUCache holding up to maxEntries entries (at least one).
+func NewLRUCache(maxEntries int) *LRUCache {
+	return &LRUCache{
+		maxEntries: max(maxEntries, 1),
+		entries:    make(map[string]*list.Element),
+		order:      list.New(),
+	}
+}
+
+// Get returns the value stored under key, if any and not expired.
+func (c *LRUCache) Get(key string) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	element, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	entry := element.Value.(*lruEntry)
+	if time.Now().After(entry.expiresAt) {
+		c.remove(element)
+		return nil, false
+	}
+	c.order.MoveToFront(element)
+	return entry.value, true
+}
+
+// Set stores value under key for the given duration, evicting the least recently used entry if the cache is full.
+func (c *LRUCache) Set(key string, value []byte, ttl time.Duration) {
+	if ttl <= 0 {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	expiresAt := time.Now().Add(ttl)
+	if element, ok := c.entries[key]; ok {
+		entry := element.Value.(*lruEntry)
+		entry.value, entry.expiresAt = value, expiresAt
+		c.order.MoveToFront(element)
+		return
+	}
+
+	c.entries[key] = c.order.PushFront(&lruEntry{key: key, value: value, expiresAt: expiresAt})
+	for c.order.Len() > c.maxEntries {
+		c.remove(c.order.Back())
+	}
+}
+
+// Len returns the number of entries in the cache, including expired ones not yet evicted.
+func (c *LRUCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len()
+}
+
+func (c *LRUCache) remove(element *list.Element) {
+	c.order.Remove(element)
+	delete(c.entries, element.Value.(*lruEntry).key)
+}
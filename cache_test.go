@@ -0,0 +1,198 @@
+package greip_test
+
+import (
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	greip "github.com/greipio/go"
+	"github.com/greipio/go/greiptest"
+)
+
+func TestCacheKey(t *testing.T) {
+	query := func(params, lang string) url.Values {
+		return url.Values{"ip": {"1.1.1.1"}, "params": {params}, "lang": {lang}}
+	}
+	tests := []struct {
+		name string
+		a, b string
+		same bool
+	}{
+		{"param order", greip.CacheKey(false, "IPLookup", query("security,location", "EN")), greip.CacheKey(false, "IPLookup", query("location,security", "EN")), true},
+		{"different params", greip.CacheKey(false, "IPLookup", query("security", "EN")), greip.CacheKey(false, "IPLookup", query("location", "EN")), false},
+		{"lang", greip.CacheKey(false, "IPLookup", query("security", "EN")), greip.CacheKey(false, "IPLookup", query("security", "AR")), false},
+		{"test and live mode", greip.CacheKey(true, "IPLookup", query("security", "EN")), greip.CacheKey(false, "IPLookup", query("security", "EN")), false},
+		{"endpoint", greip.CacheKey(false, "IPLookup", url.Values{"ip": {"1.1.1.1"}}), greip.CacheKey(false, "threats", url.Values{"ip": {"1.1.1.1"}}), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if (tt.a == tt.b) != tt.same {
+				t.Fatalf("keys %q and %q: got same %v, want %v", tt.a, tt.b, tt.a == tt.b, tt.same)
+			}
+		})
+	}
+}
+
+func TestCacheHit(t *testing.T) {
+	server := greiptest.NewServer()
+	defer server.Close()
+	client := server.Client(greip.WithCache(greip.NewLRUCache(10)))
+
+	first, err := client.Lookup("1.1.1.1", []string{"security", "location"})
+	if err != nil || first.FromCache {
+		t.Fatalf("first Lookup: %+v, %v, want a response from the API", first, err)
+	}
+	second, err := client.Lookup("1.1.1.1", []string{"location", "security"})
+	if err != nil || !second.FromCache || second.IP != "1.1.1.1" {
+		t.Fatalf("second Lookup: %+v, %v, want a response from the cache", second, err)
+	}
+	if got := len(server.RequestsTo("IPLookup")); got != 1 {
+		t.Fatalf("got %d requests, want 1", got)
+	}
+}
+
+func TestCacheSeparatesTestMode(t *testing.T) {
+	server := greiptest.NewServer()
+	defer server.Close()
+	cache := &recordingCache{Cache: greip.NewLRUCache(10)}
+
+	live := server.Client(greip.WithCache(cache))
+	test := server.Client(greip.WithCache(cache), greip.WithTestMode(true))
+	for _, client := range []*greip.Greip{live, test, live, test} {
+		if _, err := client.Lookup("1.1.1.1", nil); err != nil {
+			t.Fatalf("Lookup: %v", err)
+		}
+	}
+
+	if got := len(server.RequestsTo("IPLookup")); got != 2 {
+		t.Fatalf("got %d requests, want one per mode", got)
+	}
+	keys := cache.keys()
+	if len(keys) != 2 || !strings.HasPrefix(keys[0], "live:") || !strings.HasPrefix(keys[1], "test:") {
+		t.Fatalf("got keys %q, want a live and a test one", keys)
+	}
+}
+
+func TestCacheTTLs(t *testing.T) {
+	tests := []struct {
+		name    string
+		options []greip.Option
+		call    func(client *greip.Greip) error
+		ttl     time.Duration
+	}{
+		{"IPLookup default", nil, func(client *greip.Greip) error { _, err := client.Lookup("1.1.1.1", nil); return err }, greip.DefaultCacheTTLs["IPLookup"]},
+		{"threats default", nil, func(client *greip.Greip) error { _, err := client.Threats("1.1.1.1"); return err }, greip.DefaultCacheTTLs["threats"]},
+		{"ASN default", nil, func(client *greip.Greip) error { _, err := client.AsnLookup("AS13335"); return err }, greip.DefaultCacheTTLs["ASNLookup"]},
+		{"overridden", []greip.Option{greip.WithCacheTTL("IPLookup", time.Minute)}, func(client *greip.Greip) error { _, err := client.Lookup("1.1.1.1", nil); return err }, time.Minute},
+		{"disabled", []greip.Option{greip.WithCacheTTL("IPLookup", 0)}, func(client *greip.Greip) error { _, err := client.Lookup("1.1.1.1", nil); return err }, 0},
+		{"not cacheable", nil, func(client *greip.Greip) error { _, err := client.Email("a@example.com"); return err }, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := greiptest.NewServer()
+			defer server.Close()
+			cache := &recordingCache{Cache: greip.NewLRUCache(10)}
+			client := server.Client(append([]greip.Option{greip.WithCache(cache)}, tt.options...)...)
+
+			if err := tt.call(client); err != nil {
+				t.Fatal(err)
+			}
+			ttls := cache.ttls()
+			switch {
+			case tt.ttl == 0 && len(ttls) != 0:
+				t.Fatalf("got entries stored for %v, want none", ttls)
+			case tt.ttl != 0 && (len(ttls) != 1 || ttls[0] != tt.ttl):
+				t.Fatalf("got entries stored for %v, want one for %s", ttls, tt.ttl)
+			}
+		})
+	}
+}
+
+func TestLRUCacheExpiry(t *testing.T) {
+	cache := greip.NewLRUCache(10)
+	cache.Set("short", []byte("1"), 20*time.Millisecond)
+	cache.Set("long", []byte("2"), time.Hour)
+	cache.Set("none", []byte("3"), 0)
+
+	if _, ok := cache.Get("short"); !ok {
+		t.Fatal("entry expired too early")
+	}
+	if _, ok := cache.Get("none"); ok {
+		t.Fatal("entry without TTL was stored")
+	}
+	time.Sleep(40 * time.Millisecond)
+	if _, ok := cache.Get("short"); ok {
+		t.Fatal("expired entry was returned")
+	}
+	if value, ok := cache.Get("long"); !ok || string(value) != "2" {
+		t.Fatalf("got %q, %v, want the unexpired entry", value, ok)
+	}
+	if got := cache.Len(); got != 1 {
+		t.Fatalf("got %d entries, want the expired one to be removed", got)
+	}
+}
+
+func TestLRUCacheEviction(t *testing.T) {
+	cache := greip.NewLRUCache(2)
+	cache.Set("a", []byte("a"), time.Hour)
+	cache.Set("b", []byte("b"), time.Hour)
+	cache.Get("a")
+	cache.Set("c", []byte("c"), time.Hour)
+
+	//? b is the least recently used entry, since a was read after it was stored
+	if _, ok := cache.Get("b"); ok {
+		t.Fatal("least recently used entry was kept")
+	}
+	for _, key := range []string{"a", "c"} {
+		if _, ok := cache.Get(key); !ok {
+			t.Fatalf("entry %s was evicted", key)
+		}
+	}
+
+	//? Updating an entry doesn't add one
+	cache.Set("a", []byte("A"), time.Hour)
+	if value, _ := cache.Get("a"); cache.Len() != 2 || string(value) != "A" {
+		t.Fatalf("got %d entries and %q, want 2 entries and the new value", cache.Len(), value)
+	}
+}
+
+// ? recordingCache records the keys and TTLs of the entries stored in a cache
+type recordingCache struct {
+	greip.Cache
+	mu      sync.Mutex
+	entries []cacheEntry
+}
+
+type cacheEntry struct {
+	key string
+	ttl time.Duration
+}
+
+func (c *recordingCache) Set(key string, value []byte, ttl time.Duration) {
+	c.mu.Lock()
+	c.entries = append(c.entries, cacheEntry{key, ttl})
+	c.mu.Unlock()
+	c.Cache.Set(key, value, ttl)
+}
+
+func (c *recordingCache) keys() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var keys []string
+	for _, entry := range c.entries {
+		keys = append(keys, entry.key)
+	}
+	return keys
+}
+
+func (c *recordingCache) ttls() []time.Duration {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var ttls []time.Duration
+	for _, entry := range c.entries {
+		ttls = append(ttls, entry.ttl)
+	}
+	return ttls
+}
@@ -0,0 +1,32 @@
+package greip
+
+import "context"
+
+// Client is the set of methods exposed by *Greip. Code depending on Client instead of *Greip
+// can be tested with a fake, such as greiptest.FakeClient.
+type Client interface {
+	Lookup(ip string, params []string, lang ...string) (*ResponseLookup, error)
+	LookupContext(ctx context.Context, ip string, params []string, lang ...string) (*ResponseLookup, error)
+	LookupStream(ctx context.Context, in <-chan string, params []string, lang ...string) <-chan LookupResult
+	Threats(ip string) (*ResponseThreats, error)
+	ThreatsContext(ctx context.Context, ip string) (*ResponseThreats, error)
+	BulkLookup(ips []string, params []string, lang ...string) (*map[string]ResponseLookup, error)
+	BulkLookupContext(ctx context.Context, ips []string, params []string, lang ...string) (*map[string]ResponseLookup, error)
+	Country(countryCode string, params []string, lang ...string) (*ResponseCountry, error)
+	CountryContext(ctx context.Context, countryCode string, params []string, lang ...string) (*ResponseCountry, error)
+	Profanity(text string) (*ResponseProfanity, error)
+	ProfanityContext(ctx context.Context, text string) (*ResponseProfanity, error)
+	AsnLookup(asn string) (*ResponseASN, error)
+	AsnLookupContext(ctx context.Context, asn string) (*ResponseASN, error)
+	Email(email string) (*ResponseEmail, error)
+	EmailContext(ctx context.Context, email string) (*ResponseEmail, error)
+	Phone(phone string, countryCode string) (*ResponsePhone, error)
+	PhoneContext(ctx context.Context, phone string, countryCode string) (*ResponsePhone, error)
+	IBAN(iban string) (*ResponseIBAN, error)
+	IBANContext(ctx context.Context, iban string) (*ResponseIBAN, error)
+	Payment(data map[string]interface{}) (*ResponsePayment, error)
+	PaymentContext(ctx context.Context, data map[string]interface{}) (*ResponsePayment, error)
+	PaymentTyped(ctx context.Context, request PaymentRequest) (*ResponsePayment, error)
+}
+
+var _ Client = (*Greip)(nil)
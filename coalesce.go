@@ -0,0 +1,80 @@
+package greip
+
+import (
+	"context"
+	"sync"
+)
+
+// WithCoalescing enables or disables the coalescing of identical concurrent GET requests.
+// When enabled (the default), callers asking for the same endpoint with the same parameters
+// while a request is in flight wait for that request instead of sending their own.
+func WithCoalescing(enabled bool) Option {
+	return func(g *Greip) {
+		g.noCoalescing = !enabled
+	}
+}
+
+// ? flightGroup deduplicates identical requests in flight
+type flightGroup struct {
+	mu      sync.Mutex
+	flights map[string]*flight
+}
+
+// ? flight is a request in flight, shared by every caller waiting for it
+type flight struct {
+	done    chan struct{}
+	waiters int
+	cancel  context.CancelFunc
+
+	res *result
+	err error
+}
+
+// ? Helper function to run fn once for all the concurrent callers using the same key.
+// fn runs with a context detached from any single caller, which is cancelled only once
+// every caller has given up. The returned bool reports whether the caller joined a request
+// started by another caller.
+func (fg *flightGroup) do(ctx context.Context, key string, fn func(ctx context.Context) (*result, error)) (*result, bool, error) {
+	fg.mu.Lock()
+	if fg.flights == nil {
+		fg.flights = make(map[string]*flight)
+	}
+	f, shared := fg.flights[key]
+	if shared {
+		f.waiters++
+	} else {
+		flightCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+		f = &flight{done: make(chan struct{}), waiters: 1, cancel: cancel}
+		fg.flights[key] = f
+
+		go func() {
+			f.res, f.err = fn(flightCtx)
+			cancel()
+
+			fg.mu.Lock()
+			if fg.flights[key] == f {
+				delete(fg.flights, key)
+			}
+			fg.mu.Unlock()
+			close(f.done)
+		}()
+	}
+	fg.mu.Unlock()
+
+	select {
+	case <-f.done:
+		return f.res, shared, f.err
+	case <-ctx.Done():
+		fg.mu.Lock()
+		f.waiters--
+		if f.waiters == 0 {
+			//? Nobody is waiting anymore: abort the request and let the next caller start a new one
+			f.cancel()
+			if fg.flights[key] == f {
+				delete(fg.flights, key)
+			}
+		}
+		fg.mu.Unlock()
+		return nil, shared, ctx.Err()
+	}
+}
@@ -0,0 +1,120 @@
+package greip_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	greip "github.com/greipio/go"
+	"github.com/greipio/go/greiptest"
+)
+
+func TestCoalescingSharesOneRequest(t *testing.T) {
+	tests := []struct {
+		name     string
+		options  []greip.Option
+		requests int
+	}{
+		{"enabled", nil, 1},
+		{"disabled", []greip.Option{greip.WithCoalescing(false)}, 10},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := greiptest.NewServer()
+			defer server.Close()
+			server.SetLatency("IPLookup", 100*time.Millisecond)
+			client := server.Client(tt.options...)
+
+			var wg sync.WaitGroup
+			for i := 0; i < 10; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					response, err := client.Lookup("1.1.1.1", []string{"security"})
+					if err != nil || response.IP != "1.1.1.1" {
+						t.Errorf("Lookup: %+v, %v", response, err)
+					}
+				}()
+			}
+			wg.Wait()
+
+			if got := len(server.RequestsTo("IPLookup")); got != tt.requests {
+				t.Fatalf("got %d requests, want %d", got, tt.requests)
+			}
+		})
+	}
+}
+
+func TestCoalescingAbortsWhenEveryCallerLeaves(t *testing.T) {
+	server := greiptest.NewServer()
+	defer server.Close()
+	server.SetLatency("IPLookup", 10*time.Second)
+
+	//? Report the outcome of every attempt, to see the shared request being aborted
+	outcomes := make(chan error, 10)
+	record := func(next greip.RoundTripFunc) greip.RoundTripFunc {
+		return func(call *greip.Call) (*http.Response, error) {
+			resp, err := next(call)
+			outcomes <- err
+			return resp, err
+		}
+	}
+	client := server.Client(greip.WithMiddleware(record))
+
+	//? Two callers share the flight; the first one leaving must not abort it
+	first, cancelFirst := context.WithCancel(context.Background())
+	second, cancelSecond := context.WithCancel(context.Background())
+	var wg sync.WaitGroup
+	for _, ctx := range []context.Context{first, second} {
+		wg.Add(1)
+		go func(ctx context.Context) {
+			defer wg.Done()
+			if _, err := client.LookupContext(ctx, "1.1.1.1", nil); !errors.Is(err, context.Canceled) {
+				t.Errorf("got error %v, want context.Canceled", err)
+			}
+		}(ctx)
+	}
+	waitFor(t, func() bool { return client.FlightWaiters() == 2 && len(server.RequestsTo("IPLookup")) == 1 })
+
+	cancelFirst()
+	select {
+	case err := <-outcomes:
+		t.Fatalf("request ended with %v while a caller was still waiting", err)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	cancelSecond()
+	wg.Wait()
+	select {
+	case err := <-outcomes:
+		if !errors.Is(err, context.Canceled) {
+			t.Fatalf("got error %v, want the request to be cancelled", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("request was not aborted once every caller left")
+	}
+
+	//? A new caller starts a fresh flight instead of joining the aborted one
+	server.SetLatency("IPLookup", 0)
+	if _, err := client.Lookup("1.1.1.1", nil); err != nil {
+		t.Fatalf("Lookup after abort: %v", err)
+	}
+	if got := len(server.RequestsTo("IPLookup")); got != 2 {
+		t.Fatalf("got %d requests, want 2", got)
+	}
+}
+
+// ? Helper function to wait for a condition to become true, failing the test after a while
+func waitFor(t *testing.T, condition func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for !condition() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met in time")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
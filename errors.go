@@ -0,0 +1,149 @@
+package greip
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// Sentinel errors describing the category of a failure. They are meant to be used with errors.Is:
+//
+//	if errors.Is(err, greip.ErrRateLimited) {
+//	    // back off
+//	}
+var (
+	ErrUnauthorized  = errors.New("greip: unauthorized")
+	ErrRateLimited   = errors.New("greip: rate limited")
+	ErrQuotaExceeded = errors.New("greip: quota exceeded")
+	ErrInvalidInput  = errors.New("greip: invalid input")
+)
+
+// APIError is returned when the Greip API rejects a request, either with a non-2xx
+// HTTP status or with an `error` status in the response body.
+type APIError struct {
+	// StatusCode is the HTTP status code of the response.
+	StatusCode int
+	// Code is the error code returned by the API, if any.
+	Code string
+	// Type is the error type returned by the API, if any.
+	Type string
+	// Description is the human-readable description returned by the API, if any.
+	Description string
+	// Body is the raw response body.
+	Body []byte
+}
+
+func (e *APIError) Error() string {
+	if e.Description == "" {
+		return fmt.Sprintf("HTTP request failed with status code: %d", e.StatusCode)
+	}
+	return fmt.Sprintf("API error: %s", e.Description)
+}
+
+// Is reports whether the error belongs to the category of target, which makes
+// errors.Is(err, ErrUnauthorized) and friends work with an *APIError.
+func (e *APIError) Is(target error) bool {
+	return target != nil && target == e.category()
+}
+
+// ? Helper function to map the status code, code and description of an API error to a sentinel error
+func (e *APIError) category() error {
+	switch {
+	case e.StatusCode == http.StatusUnauthorized || e.StatusCode == http.StatusForbidden:
+		return ErrUnauthorized
+	case e.StatusCode == http.StatusPaymentRequired:
+		return ErrQuotaExceeded
+	case e.StatusCode == http.StatusTooManyRequests:
+		if e.mentions("quota", "credit", "plan") {
+			return ErrQuotaExceeded
+		}
+		return ErrRateLimited
+	case e.StatusCode == http.StatusBadRequest || e.StatusCode == http.StatusUnprocessableEntity:
+		return ErrInvalidInput
+	case e.StatusCode >= 500:
+		return nil
+	}
+
+	//? The API also reports errors with a 2xx status, so fall back to the error details
+	switch {
+	case e.mentions("token", "api key", "unauthori", "forbidden"):
+		return ErrUnauthorized
+	case e.mentions("quota", "credit", "plan limit", "upgrade"):
+		return ErrQuotaExceeded
+	case e.mentions("rate limit", "too many"):
+		return ErrRateLimited
+	case e.StatusCode >= 200 && e.StatusCode < 300:
+		return ErrInvalidInput
+	}
+	return nil
+}
+
+// ? Helper function to check whether the error code, type or description contains any of the given words
+func (e *APIError) mentions(words ...string) bool {
+	text := strings.ToLower(e.Code + " " + e.Type + " " + e.Description)
+	for _, word := range words {
+		if strings.Contains(text, word) {
+			return true
+		}
+	}
+	return false
+}
+
+// ? Helper function to build an APIError out of a response, tolerating bodies that are not a Greip envelope
+func newAPIError(statusCode int, body []byte) *APIError {
+	apiErr := &APIError{StatusCode: statusCode, Body: body}
+
+	var envelope struct {
+		Code        json.RawMessage `json:"code"`
+		Type        json.RawMessage `json:"type"`
+		Description json.RawMessage `json:"description"`
+	}
+	if err := json.Unmarshal(body, &envelope); err == nil {
+		apiErr.Code = rawString(envelope.Code)
+		apiErr.Type = rawString(envelope.Type)
+		apiErr.Description = rawString(envelope.Description)
+	}
+	return apiErr
+}
+
+// ? Helper function to turn a raw JSON scalar into a string, whether it is quoted or not
+func rawString(raw json.RawMessage) string {
+	if len(raw) == 0 || string(raw) == "null" {
+		return ""
+	}
+	var s string
+	if err := json.Unmarshal(raw, &s); err == nil {
+		return s
+	}
+	return string(raw)
+}
+
+// ValidationError is returned when the input of a method is rejected before any request is sent.
+// It matches ErrInvalidInput with errors.Is.
+type ValidationError struct {
+	// Field is the name of the invalid parameter (e.g. "ip", "params", "lang").
+	Field string
+	// Value is the rejected value, if any.
+	Value string
+	// Message describes why the value was rejected.
+	Message string
+}
+
+func (e *ValidationError) Error() string {
+	return e.Message
+}
+
+// Is makes errors.Is(err, ErrInvalidInput) report true for validation errors.
+func (e *ValidationError) Is(target error) bool {
+	return target == ErrInvalidInput
+}
+
+// ? Helper function to build the error returned when a required parameter is missing
+func missingParamError(field string) *ValidationError {
+	return &ValidationError{
+		Field:   field,
+		Message: fmt.Sprintf("you must provide the `%s` parameter", field),
+	}
+}
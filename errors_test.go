@@ -0,0 +1,123 @@
+package greip_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	greip "github.com/greipio/go"
+	"github.com/greipio/go/greiptest"
+)
+
+func TestMalformedErrorBody(t *testing.T) {
+	tests := []struct {
+		name        string
+		status      int
+		body        string
+		description string
+	}{
+		{"numeric description", http.StatusOK, `{"status":"error","description":123}`, "123"},
+		{"missing description", http.StatusOK, `{"status":"error"}`, ""},
+		{"null description", http.StatusOK, `{"status":"error","description":null,"code":null}`, ""},
+		{"object description", http.StatusBadRequest, `{"status":"error","description":{"text":"bad"}}`, `{"text":"bad"}`},
+		{"not JSON", http.StatusBadGateway, `<html>Bad Gateway</html>`, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+				_, _ = w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+			client := greip.NewClient("token", greip.WithBaseURL(server.URL), greip.WithRetryPolicy(greip.RetryPolicy{}))
+
+			_, err := client.Lookup("1.1.1.1", nil)
+			var apiErr *greip.APIError
+			if !errors.As(err, &apiErr) {
+				t.Fatalf("got error %v, want an *APIError", err)
+			}
+			if apiErr.StatusCode != tt.status || apiErr.Description != tt.description || string(apiErr.Body) != tt.body {
+				t.Fatalf("got %+v, want status %d and description %q", apiErr, tt.status, tt.description)
+			}
+		})
+	}
+}
+
+func TestAPIErrorCategories(t *testing.T) {
+	sentinels := []error{greip.ErrUnauthorized, greip.ErrRateLimited, greip.ErrQuotaExceeded, greip.ErrInvalidInput}
+	tests := []struct {
+		name        string
+		status      int
+		code        string
+		description string
+		want        error
+	}{
+		{"401", http.StatusUnauthorized, "", "Invalid API key.", greip.ErrUnauthorized},
+		{"403", http.StatusForbidden, "", "", greip.ErrUnauthorized},
+		{"402", http.StatusPaymentRequired, "", "", greip.ErrQuotaExceeded},
+		{"429 rate limit", http.StatusTooManyRequests, "", "Too many requests, slow down.", greip.ErrRateLimited},
+		{"429 quota", http.StatusTooManyRequests, "", "Your monthly quota has been used.", greip.ErrQuotaExceeded},
+		{"429 credits", http.StatusTooManyRequests, "NO_CREDITS", "", greip.ErrQuotaExceeded},
+		{"400", http.StatusBadRequest, "", "Invalid IP address.", greip.ErrInvalidInput},
+		{"422", http.StatusUnprocessableEntity, "", "", greip.ErrInvalidInput},
+		{"500", http.StatusInternalServerError, "", "Invalid token.", nil},
+		{"503", http.StatusServiceUnavailable, "", "", nil},
+		{"200 token", http.StatusOK, "", "Invalid API token.", greip.ErrUnauthorized},
+		{"200 plan limit", http.StatusOK, "", "You have reached your plan limit, please upgrade.", greip.ErrQuotaExceeded},
+		{"200 rate limit", http.StatusOK, "", "Rate limit exceeded.", greip.ErrRateLimited},
+		{"200 other", http.StatusOK, "", "Invalid IP address.", greip.ErrInvalidInput},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := greiptest.NewServer()
+			defer server.Close()
+			server.Fail("IPLookup", greiptest.Failure{StatusCode: tt.status, Code: tt.code, Description: tt.description})
+			client := server.Client(greip.WithRetryPolicy(greip.RetryPolicy{}))
+
+			_, err := client.Lookup("1.1.1.1", nil)
+			var apiErr *greip.APIError
+			if !errors.As(err, &apiErr) || apiErr.StatusCode != tt.status {
+				t.Fatalf("got error %v, want an *APIError with status %d", err, tt.status)
+			}
+			for _, sentinel := range sentinels {
+				if got, want := errors.Is(err, sentinel), sentinel == tt.want; got != want {
+					t.Errorf("errors.Is(%v, %v) = %v, want %v", err, sentinel, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestValidationError(t *testing.T) {
+	server := greiptest.NewServer()
+	defer server.Close()
+	client := server.Client()
+
+	tests := []struct {
+		name  string
+		call  func() error
+		field string
+		value string
+	}{
+		{"invalid IP", func() error { _, err := client.Lookup("not-an-ip", nil); return err }, "ip", "not-an-ip"},
+		{"missing IP", func() error { _, err := client.Lookup("", nil); return err }, "ip", ""},
+		{"invalid param", func() error { _, err := client.Lookup("1.1.1.1", []string{"weather"}); return err }, "params", "weather"},
+		{"invalid lang", func() error { _, err := client.Lookup("1.1.1.1", nil, "xx"); return err }, "lang", "xx"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.call()
+			var validationErr *greip.ValidationError
+			if !errors.As(err, &validationErr) || validationErr.Field != tt.field || validationErr.Value != tt.value {
+				t.Fatalf("got error %#v, want a *ValidationError for %s=%q", err, tt.field, tt.value)
+			}
+			if !errors.Is(err, greip.ErrInvalidInput) {
+				t.Fatalf("errors.Is(%v, ErrInvalidInput) = false", err)
+			}
+		})
+	}
+	if got := len(server.Requests()); got != 0 {
+		t.Fatalf("got %d requests, want the input to be rejected locally", got)
+	}
+}
@@ -0,0 +1,36 @@
+package greip
+
+import (
+	"context"
+	"time"
+)
+
+// Internals exposed to the external tests of the package.
+
+var NewTokenBucket = newTokenBucket
+
+func (b *tokenBucket) Reserve(now time.Time) time.Duration { return b.reserve(now) }
+
+func (b *tokenBucket) Wait(ctx context.Context) error { return b.wait(ctx) }
+
+func (g *Greip) AcquireLimits(ctx context.Context, endpoint string) (func(), error) {
+	return g.limits.acquire(ctx, endpoint)
+}
+
+func (g *Greip) FlightWaiters() int {
+	g.flights.mu.Lock()
+	defer g.flights.mu.Unlock()
+	waiters := 0
+	for _, f := range g.flights.flights {
+		waiters += f.waiters
+	}
+	return waiters
+}
+
+func (p RetryPolicy) Backoff(attempt int) (time.Duration, bool) { return p.backoff(attempt, nil) }
+
+func (b *tokenBucket) Cancel() { b.cancel() }
+
+var CacheKey = cacheKey
+
+var RedactPayload = redactPayload
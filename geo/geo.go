@@ -0,0 +1,173 @@
+// Package geo computes distances and travel speeds between Greip IP lookups, and detects
+// impossible travel between two logins, a common account-takeover signal.
+//
+// Example usage:
+//
+//	prev, err := geo.LoginFrom(previousLookup, previousLoginTime)
+//	if err != nil {
+//	    return err
+//	}
+//	curr, err := geo.LoginFrom(currentLookup, time.Now())
+//	if err != nil {
+//	    return err
+//	}
+//	if travel, impossible := geo.ImpossibleTravel(prev, curr, 900); impossible {
+//	    log.Printf("%.0f km in %s (%.0f km/h)", travel.Distance, travel.Duration, travel.Speed)
+//	}
+package geo
+
+import (
+	"errors"
+	"math"
+	"time"
+
+	greip "github.com/greipio/go"
+)
+
+// EarthRadius is the mean radius of the Earth, in kilometers, used by Distance.
+const EarthRadius = 6371.0088
+
+// DefaultAccuracyKm is the distance, in kilometers, under which two locations are considered the
+// same by a Detector that doesn't set its own, since IP geolocation is only accurate to the city or region.
+const DefaultAccuracyKm = 50.0
+
+// Errors returned by LoginFrom when a lookup lacks the data needed to build a login.
+var (
+	ErrNoCoordinates = errors.New("geo: lookup has no coordinates")
+	ErrNoTime        = errors.New("geo: lookup has no timestamp")
+)
+
+// Point is a location on Earth, in decimal degrees.
+type Point struct {
+	Lat float64
+	Lon float64
+}
+
+// PointOf returns the location of an IP lookup. ok is false if the lookup has no valid coordinates.
+func PointOf(lookup *greip.ResponseLookup) (p Point, ok bool) {
+	if lookup == nil {
+		return Point{}, false
+	}
+	lat, lon, ok := lookup.Coordinates()
+	return Point{Lat: lat, Lon: lon}, ok
+}
+
+// Distance returns the great-circle distance between a and b in kilometers, using the haversine formula.
+func Distance(a, b Point) float64 {
+	lat1, lat2 := radians(a.Lat), radians(b.Lat)
+	dLat := lat2 - lat1
+	dLon := radians(b.Lon - a.Lon)
+
+	h := math.Pow(math.Sin(dLat/2), 2) + math.Cos(lat1)*math.Cos(lat2)*math.Pow(math.Sin(dLon/2), 2)
+	return 2 * EarthRadius * math.Asin(math.Sqrt(math.Min(1, h)))
+}
+
+// DistanceBetween returns the distance between the locations of two IP lookups in kilometers.
+// ok is false if either lookup has no valid coordinates.
+func DistanceBetween(a, b *greip.ResponseLookup) (km float64, ok bool) {
+	pa, okA := PointOf(a)
+	pb, okB := PointOf(b)
+	if !okA || !okB {
+		return 0, false
+	}
+	return Distance(pa, pb), true
+}
+
+// Login is a login event: where it came from and when it happened.
+type Login struct {
+	Point
+	Time time.Time
+}
+
+// LoginFrom builds a login from the IP lookup of its origin. When at is the zero time,
+// the current time reported by the lookup (Timezone.CurrentTimeUnix, which requires the
+// "timezone" param) is used instead.
+func LoginFrom(lookup *greip.ResponseLookup, at time.Time) (Login, error) {
+	p, ok := PointOf(lookup)
+	if !ok {
+		return Login{}, ErrNoCoordinates
+	}
+	if at.IsZero() {
+		if lookup.Timezone.CurrentTimeUnix == 0 {
+			return Login{}, ErrNoTime
+		}
+		at = time.Unix(int64(lookup.Timezone.CurrentTimeUnix), 0)
+	}
+	return Login{Point: p, Time: at}, nil
+}
+
+// Travel describes the move between two logins.
+type Travel struct {
+	// Distance is the distance between the two logins, in kilometers.
+	Distance float64
+	// Duration is the time elapsed between the two logins, whatever their order.
+	Duration time.Duration
+	// Speed is the speed needed to cover Distance in Duration, in km/h.
+	Speed float64
+}
+
+// TravelBetween returns the distance, the elapsed time and the speed between two logins.
+func TravelBetween(prev, curr Login) Travel {
+	distance := Distance(prev.Point, curr.Point)
+	duration := curr.Time.Sub(prev.Time)
+	if duration < 0 {
+		duration = -duration
+	}
+	return Travel{Distance: distance, Duration: duration, Speed: speed(distance, duration)}
+}
+
+// Velocity returns the speed needed to travel between two logins, in km/h.
+// It is +Inf for logins from different places at the same time.
+func Velocity(prev, curr Login) float64 {
+	return TravelBetween(prev, curr).Speed
+}
+
+// Detector flags impossible travel between two logins.
+//
+// Example usage:
+//
+//	detector := geo.Detector{MaxKmh: 900, AccuracyKm: 100}
+//	if travel, impossible := detector.Check(prev, curr); impossible {
+//	    log.Printf("%.0f km in %s (%.0f km/h)", travel.Distance, travel.Duration, travel.Speed)
+//	}
+type Detector struct {
+	// MaxKmh is the highest plausible speed, in km/h (around 900 km/h for a commercial flight).
+	MaxKmh float64
+	// AccuracyKm is the distance, in kilometers, under which two logins are never considered
+	// impossible. Zero means DefaultAccuracyKm.
+	AccuracyKm float64
+}
+
+// Check reports whether going from prev to curr requires a speed above d.MaxKmh, for logins
+// at least d.AccuracyKm apart.
+func (d Detector) Check(prev, curr Login) (Travel, bool) {
+	accuracy := d.AccuracyKm
+	if accuracy == 0 {
+		accuracy = DefaultAccuracyKm
+	}
+	travel := TravelBetween(prev, curr)
+	return travel, travel.Distance >= accuracy && travel.Speed > d.MaxKmh
+}
+
+// ImpossibleTravel reports whether going from prev to curr requires a speed above maxKmh
+// (around 900 km/h for a commercial flight). Logins less than DefaultAccuracyKm apart are never
+// considered impossible; use a Detector to change that distance.
+func ImpossibleTravel(prev, curr Login, maxKmh float64) (Travel, bool) {
+	return Detector{MaxKmh: maxKmh}.Check(prev, curr)
+}
+
+// ? Helper function to compute a speed in km/h
+func speed(km float64, d time.Duration) float64 {
+	switch {
+	case km == 0:
+		return 0
+	case d == 0:
+		return math.Inf(1)
+	}
+	return km / d.Hours()
+}
+
+// ? Helper function to convert degrees to radians
+func radians(deg float64) float64 {
+	return deg * math.Pi / 180
+}
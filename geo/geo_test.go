@@ -0,0 +1,88 @@
+package geo_test
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/greipio/go/geo"
+)
+
+var (
+	paris      = geo.Point{Lat: 48.8566, Lon: 2.3522}
+	london     = geo.Point{Lat: 51.5074, Lon: -0.1278}
+	newYork    = geo.Point{Lat: 40.7128, Lon: -74.0060}
+	losAngeles = geo.Point{Lat: 34.0522, Lon: -118.2437}
+)
+
+func TestDistance(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b geo.Point
+		km   float64
+	}{
+		{"Paris to London", paris, london, 343.6},
+		{"New York to Los Angeles", newYork, losAngeles, 3935.9},
+		{"same point", paris, paris, 0},
+		{"antipodes", geo.Point{Lat: 0, Lon: 0}, geo.Point{Lat: 0, Lon: 180}, math.Pi * geo.EarthRadius},
+		{"across the antimeridian", geo.Point{Lat: 0, Lon: 179.5}, geo.Point{Lat: 0, Lon: -179.5}, 111.2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := geo.Distance(tt.a, tt.b); math.Abs(got-tt.km) > 1 {
+				t.Fatalf("got %.1f km, want %.1f km", got, tt.km)
+			}
+			if got, back := geo.Distance(tt.a, tt.b), geo.Distance(tt.b, tt.a); got != back {
+				t.Fatalf("distance is not symmetric: %f and %f", got, back)
+			}
+		})
+	}
+}
+
+func TestDetector(t *testing.T) {
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	login := func(p geo.Point, after time.Duration) geo.Login {
+		return geo.Login{Point: p, Time: start.Add(after)}
+	}
+
+	tests := []struct {
+		name       string
+		detector   geo.Detector
+		prev, curr geo.Login
+		impossible bool
+	}{
+		{"Paris to London in an hour", geo.Detector{MaxKmh: 900}, login(paris, 0), login(london, time.Hour), false},
+		{"Paris to London in ten minutes", geo.Detector{MaxKmh: 900}, login(paris, 0), login(london, 10*time.Minute), true},
+		{"logins in reverse order", geo.Detector{MaxKmh: 900}, login(london, 10*time.Minute), login(paris, 0), true},
+		{"within the default accuracy", geo.Detector{MaxKmh: 900}, login(paris, 0), login(geo.Point{Lat: 49.2, Lon: 2.3522}, 0), false},
+		{"beyond a smaller accuracy", geo.Detector{MaxKmh: 900, AccuracyKm: 10}, login(paris, 0), login(geo.Point{Lat: 49.2, Lon: 2.3522}, 0), true},
+		{"within a larger accuracy", geo.Detector{MaxKmh: 900, AccuracyKm: 400}, login(paris, 0), login(london, time.Minute), false},
+		{"same place at the same time", geo.Detector{MaxKmh: 900}, login(paris, 0), login(paris, 0), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			travel, impossible := tt.detector.Check(tt.prev, tt.curr)
+			if impossible != tt.impossible {
+				t.Fatalf("got impossible %v for %+v, want %v", impossible, travel, tt.impossible)
+			}
+			if travel.Duration < 0 || travel.Distance != geo.Distance(tt.prev.Point, tt.curr.Point) {
+				t.Fatalf("got travel %+v", travel)
+			}
+		})
+	}
+}
+
+func TestImpossibleTravelUsesDefaultAccuracy(t *testing.T) {
+	start := time.Now()
+	prev := geo.Login{Point: paris, Time: start}
+	curr := geo.Login{Point: london, Time: start.Add(10 * time.Minute)}
+
+	travel, impossible := geo.ImpossibleTravel(prev, curr, 900)
+	want, wantImpossible := geo.Detector{MaxKmh: 900, AccuracyKm: geo.DefaultAccuracyKm}.Check(prev, curr)
+	if travel != want || impossible != wantImpossible || !impossible {
+		t.Fatalf("got %+v, %v, want %+v, %v", travel, impossible, want, wantImpossible)
+	}
+	if math.Abs(travel.Speed-6*travel.Distance) > 1e-6 {
+		t.Fatalf("got speed %f km/h for %f km in 10 minutes", travel.Speed, travel.Distance)
+	}
+}
@@ -0,0 +1,328 @@
+// Package geopolicy evaluates declarative allow/deny/challenge policies against Greip IP lookups
+// and country data, so that embargo lists and risk rules can change without a code deploy.
+//
+// A policy is an ordered list of rules. The first rule matching the lookup gives the decision,
+// and the default action applies when none does. Within a rule, every condition that is set must
+// hold, and a list condition holds when any of its values matches.
+//
+// Example policy, in YAML:
+//
+//	default: allow
+//	rules:
+//	  - name: embargo
+//	    action: deny
+//	    reason: embargoed country
+//	    match:
+//	      countries: [CU, IR, KP, SY]
+//	  - name: anonymizers
+//	    action: challenge
+//	    match:
+//	      tor: true
+//	  - name: eu-hosting
+//	    action: challenge
+//	    match:
+//	      eu: true
+//	      hosting: true
+//
+// Example usage:
+//
+//	policy, err := geopolicy.LoadFile("policy.yaml")
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	lookup, err := greipInstance.Lookup(ip, []string{"location", "security"})
+//	if err != nil {
+//	    return err
+//	}
+//	if decision := policy.Evaluate(lookup); decision.Action == geopolicy.Deny {
+//	    http.Error(w, decision.Reason, http.StatusForbidden)
+//	}
+package geopolicy
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	greip "github.com/greipio/go"
+	"gopkg.in/yaml.v3"
+)
+
+// Action is the outcome of a policy.
+type Action string
+
+const (
+	Allow     Action = "allow"
+	Deny      Action = "deny"
+	Challenge Action = "challenge"
+)
+
+// Decision is the result of the evaluation of a policy.
+type Decision struct {
+	Action Action
+	// Reason explains the decision: the reason of the matching rule, or "default".
+	Reason string
+	// Rule is the name of the matching rule, empty when the default action applies.
+	Rule string
+}
+
+// Policy is an ordered list of rules, with a default action.
+type Policy struct {
+	// Default is the action taken when no rule matches, Allow when empty.
+	Default Action `json:"default,omitempty" yaml:"default,omitempty"`
+	Rules   []Rule `json:"rules" yaml:"rules"`
+}
+
+// Rule gives an action to the lookups matching its conditions.
+type Rule struct {
+	Name   string `json:"name,omitempty" yaml:"name,omitempty"`
+	Action Action `json:"action" yaml:"action"`
+	// Reason is reported in the decision, the name or the position of the rule when empty.
+	Reason string `json:"reason,omitempty" yaml:"reason,omitempty"`
+	Match  Match  `json:"match" yaml:"match"`
+}
+
+// Match holds the conditions of a rule. Unset conditions are ignored, so an empty Match
+// matches everything. The conditions on the ASN and the security flags never match country
+// data, and the security flags require the "security" param of Lookup.
+type Match struct {
+	// Countries are ISO 3166-1 alpha-2 country codes (e.g. "FR").
+	Countries []string `json:"countries,omitempty" yaml:"countries,omitempty"`
+	// Continents are continent codes (e.g. "EU", "AS").
+	Continents []string `json:"continents,omitempty" yaml:"continents,omitempty"`
+	// EU matches on the membership of the country in the European Union, which Lookup only
+	// reports with the "location" param.
+	EU *bool `json:"eu,omitempty" yaml:"eu,omitempty"`
+	// ASNs are autonomous system numbers, with or without the "AS" prefix.
+	ASNs ASNList `json:"asns,omitempty" yaml:"asns,omitempty"`
+	// ASNTypes are types of autonomous systems (e.g. "hosting", "isp", "business").
+	ASNTypes []string `json:"asn_types,omitempty" yaml:"asn_types,omitempty"`
+
+	Proxy   *bool `json:"proxy,omitempty" yaml:"proxy,omitempty"`
+	Tor     *bool `json:"tor,omitempty" yaml:"tor,omitempty"`
+	Bot     *bool `json:"bot,omitempty" yaml:"bot,omitempty"`
+	Relay   *bool `json:"relay,omitempty" yaml:"relay,omitempty"`
+	Hosting *bool `json:"hosting,omitempty" yaml:"hosting,omitempty"`
+}
+
+// ASNList is a list of autonomous system numbers, which accepts both numbers and strings
+// such as "AS13335" when decoded from JSON.
+type ASNList []string
+
+// UnmarshalJSON decodes a list mixing numbers and strings.
+func (l *ASNList) UnmarshalJSON(data []byte) error {
+	var values []interface{}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(&values); err != nil {
+		return err
+	}
+
+	*l = make(ASNList, len(values))
+	for i, value := range values {
+		switch value := value.(type) {
+		case string:
+			(*l)[i] = value
+		case json.Number:
+			(*l)[i] = value.String()
+		default:
+			return fmt.Errorf("geopolicy: invalid ASN: %v", value)
+		}
+	}
+	return nil
+}
+
+// Parse decodes a policy written in YAML or JSON, and validates it.
+// Unknown fields are rejected, so that a typo can't silently disable a condition.
+func Parse(data []byte) (*Policy, error) {
+	var policy Policy
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '{' {
+		decoder := json.NewDecoder(bytes.NewReader(trimmed))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(&policy); err != nil {
+			return nil, fmt.Errorf("geopolicy: %w", err)
+		}
+	} else {
+		decoder := yaml.NewDecoder(bytes.NewReader(data))
+		decoder.KnownFields(true)
+		if err := decoder.Decode(&policy); err != nil {
+			return nil, fmt.Errorf("geopolicy: %w", err)
+		}
+	}
+
+	if err := policy.Validate(); err != nil {
+		return nil, err
+	}
+	return &policy, nil
+}
+
+// LoadFile reads and parses the policy stored in the file at path, in YAML or JSON.
+func LoadFile(path string) (*Policy, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return Parse(data)
+}
+
+// Validate checks that the actions of the policy are known and that its ASNs are numbers.
+func (p *Policy) Validate() error {
+	if p.Default != "" && !p.Default.valid() {
+		return fmt.Errorf("geopolicy: invalid default action: %q", p.Default)
+	}
+	for i, rule := range p.Rules {
+		name := rule.Name
+		if name == "" {
+			name = fmt.Sprintf("#%d", i+1)
+		}
+		if !rule.Action.valid() {
+			return fmt.Errorf("geopolicy: rule %s: invalid action: %q", name, rule.Action)
+		}
+		for _, asn := range rule.Match.ASNs {
+			if _, err := strconv.ParseUint(normalizeASN(asn), 10, 32); err != nil {
+				return fmt.Errorf("geopolicy: rule %s: invalid ASN: %q", name, asn)
+			}
+		}
+	}
+	return nil
+}
+
+// Evaluate returns the decision of the policy for an IP lookup.
+func (p *Policy) Evaluate(lookup *greip.ResponseLookup) Decision {
+	if lookup == nil {
+		return p.decide(subject{})
+	}
+	return p.decide(subject{
+		country:   lookup.CountryCode,
+		continent: lookup.ContinentCode,
+		eu:        lookup.Location.CountryIsEU,
+		network:   true,
+		asn:       lookup.ASN.Number,
+		asnType:   lookup.ASN.Type,
+		security:  lookup.Security,
+	})
+}
+
+// EvaluateCountry returns the decision of the policy for country data. Rules with conditions
+// on the ASN or the security flags never match.
+func (p *Policy) EvaluateCountry(country *greip.ResponseCountry) Decision {
+	if country == nil {
+		return p.decide(subject{})
+	}
+	return p.decide(subject{
+		country:   country.CountryCode,
+		continent: country.ContinentCode,
+		eu:        country.CountryIsEU,
+	})
+}
+
+// ? subject holds the data a policy is evaluated against
+type subject struct {
+	country   string
+	continent string
+	eu        bool
+
+	//? Only IP lookups carry network data
+	network  bool
+	asn      string
+	asnType  string
+	security greip.LookupSecurity
+}
+
+// ? Helper function to find the first matching rule
+func (p *Policy) decide(s subject) Decision {
+	for i, rule := range p.Rules {
+		if rule.Match.matches(s) {
+			reason := rule.Reason
+			if reason == "" {
+				reason = rule.Name
+			}
+			if reason == "" {
+				reason = fmt.Sprintf("rule #%d", i+1)
+			}
+			return Decision{Action: rule.Action, Reason: reason, Rule: rule.Name}
+		}
+	}
+
+	action := p.Default
+	if action == "" {
+		action = Allow
+	}
+	return Decision{Action: action, Reason: "default"}
+}
+
+// ? Helper function to check whether every condition set on the match holds
+func (m *Match) matches(s subject) bool {
+	if len(m.Countries) > 0 && !containsFold(m.Countries, s.country) {
+		return false
+	}
+	if len(m.Continents) > 0 && !containsFold(m.Continents, s.continent) {
+		return false
+	}
+	if m.EU != nil && *m.EU != s.eu {
+		return false
+	}
+
+	networkConditions := []struct {
+		set   bool
+		holds func() bool
+	}{
+		{len(m.ASNs) > 0, func() bool { return m.matchesASN(s.asn) }},
+		{len(m.ASNTypes) > 0, func() bool { return containsFold(m.ASNTypes, s.asnType) }},
+		{m.Proxy != nil, func() bool { return *m.Proxy == s.security.IsProxy }},
+		{m.Tor != nil, func() bool { return *m.Tor == s.security.IsTor }},
+		{m.Bot != nil, func() bool { return *m.Bot == s.security.IsBot }},
+		{m.Relay != nil, func() bool { return *m.Relay == s.security.IsRelay }},
+		{m.Hosting != nil, func() bool { return *m.Hosting == s.security.IsHosting }},
+	}
+	for _, condition := range networkConditions {
+		if condition.set && (!s.network || !condition.holds()) {
+			return false
+		}
+	}
+	return true
+}
+
+// ? Helper function to check whether an ASN is listed
+func (m *Match) matchesASN(asn string) bool {
+	asn = normalizeASN(asn)
+	if asn == "" {
+		return false
+	}
+	for _, listed := range m.ASNs {
+		if normalizeASN(listed) == asn {
+			return true
+		}
+	}
+	return false
+}
+
+// ? Helper function to strip the "AS" prefix of an ASN
+func normalizeASN(asn string) string {
+	asn = strings.TrimSpace(asn)
+	if len(asn) >= 2 && strings.EqualFold(asn[:2], "AS") {
+		asn = asn[2:]
+	}
+	return asn
+}
+
+// ? Helper function to check if a slice contains a value, ignoring case
+func containsFold(slice []string, item string) bool {
+	if item == "" {
+		return false
+	}
+	for _, v := range slice {
+		if strings.EqualFold(strings.TrimSpace(v), item) {
+			return true
+		}
+	}
+	return false
+}
+
+// ? Helper function to check that an action is known
+func (a Action) valid() bool {
+	return a == Allow || a == Deny || a == Challenge
+}
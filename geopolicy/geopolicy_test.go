@@ -0,0 +1,165 @@
+package geopolicy_test
+
+import (
+	"strings"
+	"testing"
+
+	greip "github.com/greipio/go"
+	"github.com/greipio/go/geopolicy"
+)
+
+const yamlPolicy = `
+default: allow
+rules:
+  - name: embargo
+    action: deny
+    reason: embargoed country
+    match:
+      countries: [cu, IR]
+  - name: search-engines
+    action: allow
+    match:
+      asns: [13335, "AS15169"]
+  - name: tor
+    action: deny
+    match:
+      tor: true
+  - name: eu-hosting
+    action: challenge
+    match:
+      eu: true
+      hosting: true
+  - action: challenge
+    match:
+      continents: [AS]
+`
+
+const jsonPolicy = `{
+  "default": "allow",
+  "rules": [
+    {"name": "embargo", "action": "deny", "reason": "embargoed country", "match": {"countries": ["cu", "IR"]}},
+    {"name": "search-engines", "action": "allow", "match": {"asns": [13335, "AS15169"]}},
+    {"name": "tor", "action": "deny", "match": {"tor": true}},
+    {"name": "eu-hosting", "action": "challenge", "match": {"eu": true, "hosting": true}},
+    {"action": "challenge", "match": {"continents": ["AS"]}}
+  ]
+}`
+
+func TestEvaluate(t *testing.T) {
+	lookup := func(country, continent string, eu bool, asn string, security greip.LookupSecurity) *greip.ResponseLookup {
+		return &greip.ResponseLookup{
+			CountryCode:   country,
+			ContinentCode: continent,
+			Location:      greip.LookupLocation{CountryIsEU: eu},
+			ASN:           greip.LookupASN{Number: asn},
+			Security:      security,
+		}
+	}
+	tor := greip.LookupSecurity{IsTor: true}
+	hosting := greip.LookupSecurity{IsHosting: true}
+
+	tests := []struct {
+		name   string
+		lookup *greip.ResponseLookup
+		want   geopolicy.Decision
+	}{
+		{"embargo before tor", lookup("IR", "AS", false, "", tor), geopolicy.Decision{Action: geopolicy.Deny, Reason: "embargoed country", Rule: "embargo"}},
+		{"country codes ignore case", lookup("CU", "NA", false, "", greip.LookupSecurity{}), geopolicy.Decision{Action: geopolicy.Deny, Reason: "embargoed country", Rule: "embargo"}},
+		{"numeric ASN before tor", lookup("US", "NA", false, "13335", tor), geopolicy.Decision{Action: geopolicy.Allow, Reason: "search-engines", Rule: "search-engines"}},
+		{"prefixed ASN", lookup("US", "NA", false, "AS15169", greip.LookupSecurity{}), geopolicy.Decision{Action: geopolicy.Allow, Reason: "search-engines", Rule: "search-engines"}},
+		{"tor", lookup("US", "NA", false, "64496", tor), geopolicy.Decision{Action: geopolicy.Deny, Reason: "tor", Rule: "tor"}},
+		{"every condition holds", lookup("FR", "EU", true, "", hosting), geopolicy.Decision{Action: geopolicy.Challenge, Reason: "eu-hosting", Rule: "eu-hosting"}},
+		{"one condition fails", lookup("FR", "EU", true, "", greip.LookupSecurity{}), geopolicy.Decision{Action: geopolicy.Allow, Reason: "default"}},
+		{"unnamed rule", lookup("JP", "AS", false, "", greip.LookupSecurity{}), geopolicy.Decision{Action: geopolicy.Challenge, Reason: "rule #5"}},
+		{"no lookup", nil, geopolicy.Decision{Action: geopolicy.Allow, Reason: "default"}},
+	}
+	for format, data := range map[string]string{"yaml": yamlPolicy, "json": jsonPolicy} {
+		policy, err := geopolicy.Parse([]byte(data))
+		if err != nil {
+			t.Fatalf("%s: %v", format, err)
+		}
+		for _, tt := range tests {
+			t.Run(format+"/"+tt.name, func(t *testing.T) {
+				if got := policy.Evaluate(tt.lookup); got != tt.want {
+					t.Fatalf("got %+v, want %+v", got, tt.want)
+				}
+			})
+		}
+	}
+}
+
+func TestEvaluateCountry(t *testing.T) {
+	policy, err := geopolicy.Parse([]byte(`
+default: deny
+rules:
+  - name: embargo
+    action: deny
+    match:
+      countries: [IR]
+  - name: eu-hosting
+    action: challenge
+    match:
+      eu: true
+      hosting: false
+  - name: asn
+    action: challenge
+    match:
+      asns: ["0"]
+  - name: eu
+    action: allow
+    match:
+      eu: true
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		country *greip.ResponseCountry
+		want    geopolicy.Decision
+	}{
+		{"country rule", &greip.ResponseCountry{CountryCode: "IR"}, geopolicy.Decision{Action: geopolicy.Deny, Reason: "embargo", Rule: "embargo"}},
+		//? Country data has no security flags, so hosting: false must not hold
+		{"network conditions never match", &greip.ResponseCountry{CountryCode: "FR", CountryIsEU: true}, geopolicy.Decision{Action: geopolicy.Allow, Reason: "eu", Rule: "eu"}},
+		{"default", &greip.ResponseCountry{CountryCode: "US"}, geopolicy.Decision{Action: geopolicy.Deny, Reason: "default"}},
+		{"no country", nil, geopolicy.Decision{Action: geopolicy.Deny, Reason: "default"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := policy.EvaluateCountry(tt.country); got != tt.want {
+				t.Fatalf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+
+	//? The same rule matches a lookup, which carries network data
+	lookup := &greip.ResponseLookup{CountryCode: "FR", Location: greip.LookupLocation{CountryIsEU: true}}
+	if got := policy.Evaluate(lookup); got.Rule != "eu-hosting" {
+		t.Fatalf("got %+v, want the eu-hosting rule to match a lookup", got)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want string
+	}{
+		{"unknown YAML field", "rules:\n  - action: deny\n    match:\n      countrys: [FR]\n", "countrys"},
+		{"unknown JSON field", `{"rules": [{"action": "deny", "match": {"countrys": ["FR"]}}]}`, "countrys"},
+		{"unknown top-level field", "default: allow\nrulez: []\n", "rulez"},
+		{"invalid action", "rules:\n  - name: block\n    action: block\n", `rule block: invalid action: "block"`},
+		{"invalid default", `{"default": "maybe", "rules": []}`, `invalid default action: "maybe"`},
+		{"invalid YAML ASN", "rules:\n  - action: deny\n    match:\n      asns: [ASfoo]\n", `rule #1: invalid ASN: "ASfoo"`},
+		{"invalid JSON ASN", `{"rules": [{"action": "deny", "match": {"asns": [true]}}]}`, "invalid ASN"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			policy, err := geopolicy.Parse([]byte(tt.data))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("got %+v, %v, want an error mentioning %s", policy, err, tt.want)
+			}
+		})
+	}
+}
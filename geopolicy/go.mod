@@ -0,0 +1,8 @@
+module github.com/greipio/go/geopolicy
+
+go 1.22.1
+
+require (
+	github.com/greipio/go v1.1.0
+	gopkg.in/yaml.v3 v3.0.1
+)
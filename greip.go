@@ -1,8 +1,8 @@
 package greip
 
 import (
-	"errors"
-	"net/url"
+	"context"
+	"net/http"
 	"strings"
 )
 
@@ -11,6 +11,9 @@ var availableCountryParams = []string{"language", "flag", "currency", "timezone"
 var baseUrl = "https://greipapi.com/"
 
 // NewGreip initializes a new Greip instance
+//
+// The optional test flag enables the test mode. Use NewClient to configure the instance
+// with options.
 func NewGreip(apiToken string, test ...bool) *Greip {
 	//? If the user provides a value for test, use it; otherwise, default to false.
 	testValue := false
@@ -18,11 +21,31 @@ func NewGreip(apiToken string, test ...bool) *Greip {
 		testValue = test[0]
 	}
 
-	return &Greip{
+	return NewClient(apiToken, WithTestMode(testValue))
+}
+
+// NewClient initializes a new Greip instance configured with options, for example:
+//
+//	greipInstance := greip.NewClient("YOUR_API_TOKEN",
+//	    greip.WithTimeout(5*time.Second),
+//	    greip.WithUserAgent("my-service/1.0"),
+//	)
+func NewClient(apiToken string, options ...Option) *Greip {
+	g := &Greip{
 		token:   apiToken,
 		BaseURL: baseUrl,
-		test:    testValue,
 	}
+
+	//? Apply the options, then build the HTTP client and the middleware chain shared by every request
+	for _, option := range options {
+		if option != nil {
+			option(g)
+		}
+	}
+	g.buildHTTPClient()
+	g.buildChain()
+
+	return g
 }
 
 // Lookup performs an IP lookup request to the Greip API to retrieve details
@@ -65,12 +88,21 @@ func NewGreip(apiToken string, test ...bool) *Greip {
 //   - This function uses the provided API token stored in the Greip instance to authorize
 //     the request. Ensure that a valid token is set when initializing the Greip instance.
 //   - If the `params` parameter is nil, the API will return the default set of data.
+//   - The IP address is normalised before being sent, and non-routable addresses are handled
+//     locally according to WithNonRoutableIPs.
 //   - It is recommended to handle any errors returned by this function to ensure robust code execution.
 //
 // Errors:
 //   - Network-related errors (e.g., timeouts, unreachable server).
 //   - API-related errors (e.g., invalid API token, malformed IP address).
+//   - *ValidationError for malformed IP addresses and *NonRoutableIPError for non-routable ones.
 func (g *Greip) Lookup(ip string, params []string, lang ...string) (*ResponseLookup, error) {
+	return g.LookupContext(context.Background(), ip, params, lang...)
+}
+
+// LookupContext is like Lookup but uses ctx for the outgoing HTTP request,
+// so cancelling ctx or reaching its deadline aborts the call.
+func (g *Greip) LookupContext(ctx context.Context, ip string, params []string, lang ...string) (*ResponseLookup, error) {
 	//? If no params are provided, params will be an empty slice
 	if params == nil {
 		params = []string{} // Optional, as it will be nil by default
@@ -82,17 +114,18 @@ func (g *Greip) Lookup(ip string, params []string, lang ...string) (*ResponseLoo
 		langValue = lang[0]
 	}
 
+	//? Validate and normalise the input IP
+	addr, err := normalizeIP("ip", ip)
+	if err != nil {
+		return nil, err
+	}
+
 	payload := map[string]interface{}{
-		"ip":     ip,
+		"ip":     addr.String(),
 		"params": strings.Join(params, ","),
 		"lang":   strings.ToUpper(langValue),
 	}
 
-	//? Validate the input IP
-	if ip == "" {
-		return nil, errors.New("you must provide the `ip` parameter")
-	}
-
 	//? Validate the params list
 	if params == nil {
 		params = []string{}
@@ -108,20 +141,22 @@ func (g *Greip) Lookup(ip string, params []string, lang ...string) (*ResponseLoo
 		return nil, err
 	}
 
-	//? Construct the query parameters
-	query := url.Values{}
-	query.Set("ip", ip)
-	query.Set("params", strings.Join(params, ","))
-	query.Set("lang", strings.ToUpper(langValue))
+	//? Don't spend a request on non-routable IPs
+	if handled, err := g.checkRoutable(addr); handled {
+		if err != nil {
+			return nil, err
+		}
+		response := privateNetworkLookup(addr)
+		return &response, nil
+	}
 
 	//? Make the HTTP request
-	var response ResponseLookup
-	err := g.getRequest("IPLookup", &response, payload)
+	response, _, err := do[ResponseLookup](ctx, g, http.MethodGet, "IPLookup", payload)
 	if err != nil {
 		return nil, err
 	}
 
-	return &response, err
+	return &response, nil
 }
 
 // Threats performs a threat lookup using the Greip API to check if the specified IP address
@@ -151,33 +186,46 @@ func (g *Greip) Lookup(ip string, params []string, lang ...string) (*ResponseLoo
 // Notes:
 //   - This function uses the provided API token stored in the Greip instance to authorize
 //     the request. Ensure that a valid token is set when initializing the Greip instance.
+//   - The IP address is normalised before being sent, and non-routable addresses are handled
+//     locally according to WithNonRoutableIPs.
 //   - It is recommended to handle any errors returned by this function to ensure robust code execution.
 //
 // Errors:
 //   - Network-related errors (e.g., timeouts, unreachable server).
 //   - API-related errors (e.g., invalid API token, malformed IP address).
+//   - *ValidationError for malformed IP addresses and *NonRoutableIPError for non-routable ones.
 func (g *Greip) Threats(ip string) (*ResponseThreats, error) {
-	payload := map[string]interface{}{
-		"ip": ip,
+	return g.ThreatsContext(context.Background(), ip)
+}
+
+// ThreatsContext is like Threats but uses ctx for the outgoing HTTP request,
+// so cancelling ctx or reaching its deadline aborts the call.
+func (g *Greip) ThreatsContext(ctx context.Context, ip string) (*ResponseThreats, error) {
+	//? Validate and normalise the input IP
+	addr, err := normalizeIP("ip", ip)
+	if err != nil {
+		return nil, err
 	}
 
-	//? Validate the input IP
-	if ip == "" {
-		return nil, errors.New("you must provide the `ip` parameter")
+	//? Don't spend a request on non-routable IPs
+	if handled, err := g.checkRoutable(addr); handled {
+		if err != nil {
+			return nil, err
+		}
+		return &ResponseThreats{IP: addr.String(), NonRoutable: true}, nil
 	}
 
-	//? Construct the query parameters
-	query := url.Values{}
-	query.Set("ip", ip)
+	payload := map[string]interface{}{
+		"ip": addr.String(),
+	}
 
 	//? Make the HTTP request
-	var response ResponseThreats
-	err := g.getRequest("threats", &response, payload)
+	response, _, err := do[ResponseThreats](ctx, g, http.MethodGet, "threats", payload)
 	if err != nil {
 		return nil, err
 	}
 
-	return &response, err
+	return &response, nil
 }
 
 // Performs a bulk IP lookup using the Greip API to retrieve details about multiple IP addresses
@@ -197,6 +245,9 @@ func (g *Greip) Threats(ip string) (*ResponseThreats, error) {
 //
 //   - error: An error object if any issues occur during the bulk lookup request, such as
 //     network failures or invalid responses from the API. It returns nil if the request succeeds.
+//     When only some of the chunks fail, the results of the others are returned along with
+//     a *BulkLookupError listing the error of each IP address that could not be looked up,
+//     including malformed and non-routable ones, which are never sent.
 //
 // Example usage:
 //
@@ -219,12 +270,21 @@ func (g *Greip) Threats(ip string) (*ResponseThreats, error) {
 //   - This function uses the provided API token stored in the Greip instance to authorize
 //     the request. Ensure that a valid token is set when initializing the Greip instance.
 //   - If the `params` parameter is nil, the API will return the default set of data.
+//   - The IP addresses are split into chunks of 20 (see WithBulkChunkSize), which are looked up
+//     in parallel (see WithBulkConcurrency), so the list can be of any length.
 //   - It is recommended to handle any errors returned by this function to ensure robust code execution.
 //
 // Errors:
 //   - Network-related errors (e.g., timeouts, unreachable server).
 //   - API-related errors (e.g., invalid API token, malformed IP address).
+//   - *BulkLookupError when only part of the IP addresses could be looked up.
 func (g *Greip) BulkLookup(ips []string, params []string, lang ...string) (*map[string]ResponseLookup, error) {
+	return g.BulkLookupContext(context.Background(), ips, params, lang...)
+}
+
+// BulkLookupContext is like BulkLookup but uses ctx for the outgoing HTTP request,
+// so cancelling ctx or reaching its deadline aborts the call.
+func (g *Greip) BulkLookupContext(ctx context.Context, ips []string, params []string, lang ...string) (*map[string]ResponseLookup, error) {
 	//? If no params are provided, params will be an empty slice
 	if params == nil {
 		params = []string{} // Optional, as it will be nil by default
@@ -236,15 +296,9 @@ func (g *Greip) BulkLookup(ips []string, params []string, lang ...string) (*map[
 		langValue = lang[0]
 	}
 
-	payload := map[string]interface{}{
-		"ips":    strings.Join(ips, ","),
-		"params": strings.Join(params, ","),
-		"lang":   strings.ToUpper(langValue),
-	}
-
 	//? Validate the input IPs
 	if ips == nil {
-		return nil, errors.New("you must provide the `ips` parameter")
+		return nil, missingParamError("ips")
 	}
 
 	//? Validate the params list
@@ -262,20 +316,8 @@ func (g *Greip) BulkLookup(ips []string, params []string, lang ...string) (*map[
 		return nil, err
 	}
 
-	//? Construct the query parameters
-	query := url.Values{}
-	query.Set("ips", strings.Join(ips, ","))
-	query.Set("params", strings.Join(params, ","))
-	query.Set("lang", strings.ToUpper(langValue))
-
-	//? Make the HTTP request
-	var response map[string]ResponseLookup
-	err := g.getRequest("BulkLookup", &response, payload)
-	if err != nil {
-		return nil, err
-	}
-
-	return &response, err
+	//? Split the IPs into chunks and look them up in parallel
+	return g.bulkLookup(ctx, ips, params, strings.ToUpper(langValue))
 }
 
 // Performs a country lookup using the Greip API to retrieve details about the specified country code,
@@ -323,6 +365,12 @@ func (g *Greip) BulkLookup(ips []string, params []string, lang ...string) (*map[
 //   - Network-related errors (e.g., timeouts, unreachable server).
 //   - API-related errors (e.g., invalid API token, malformed country code).
 func (g *Greip) Country(countryCode string, params []string, lang ...string) (*ResponseCountry, error) {
+	return g.CountryContext(context.Background(), countryCode, params, lang...)
+}
+
+// CountryContext is like Country but uses ctx for the outgoing HTTP request,
+// so cancelling ctx or reaching its deadline aborts the call.
+func (g *Greip) CountryContext(ctx context.Context, countryCode string, params []string, lang ...string) (*ResponseCountry, error) {
 	//? If no params are provided, params will be an empty slice
 	if params == nil {
 		params = []string{} // Optional, as it will be nil by default
@@ -342,7 +390,7 @@ func (g *Greip) Country(countryCode string, params []string, lang ...string) (*R
 
 	//? Validate the input countryCode
 	if countryCode == "" {
-		return nil, errors.New("you must provide the `countryCode` parameter")
+		return nil, missingParamError("countryCode")
 	}
 
 	//? Validate the params list
@@ -360,20 +408,13 @@ func (g *Greip) Country(countryCode string, params []string, lang ...string) (*R
 		return nil, err
 	}
 
-	//? Construct the query parameters
-	query := url.Values{}
-	query.Set("CountryCode", countryCode)
-	query.Set("params", strings.Join(params, ","))
-	query.Set("lang", strings.ToUpper(langValue))
-
 	//? Make the HTTP request
-	var response ResponseCountry
-	err := g.getRequest("Country", &response, payload)
+	response, _, err := do[ResponseCountry](ctx, g, http.MethodGet, "Country", payload)
 	if err != nil {
 		return nil, err
 	}
 
-	return &response, err
+	return &response, nil
 }
 
 // Performs a profanity check using the Greip API to detect any profane or inappropriate language
@@ -410,27 +451,28 @@ func (g *Greip) Country(countryCode string, params []string, lang ...string) (*R
 //   - Network-related errors (e.g., timeouts, unreachable server).
 //   - API-related errors (e.g., invalid API token, empty text).
 func (g *Greip) Profanity(text string) (*ResponseProfanity, error) {
+	return g.ProfanityContext(context.Background(), text)
+}
+
+// ProfanityContext is like Profanity but uses ctx for the outgoing HTTP request,
+// so cancelling ctx or reaching its deadline aborts the call.
+func (g *Greip) ProfanityContext(ctx context.Context, text string) (*ResponseProfanity, error) {
 	payload := map[string]interface{}{
 		"text": text,
 	}
 
 	//? Validate the input text
 	if text == "" {
-		return nil, errors.New("you must provide the `text` parameter")
+		return nil, missingParamError("text")
 	}
 
-	//? Construct the query parameters
-	query := url.Values{}
-	query.Set("text", text)
-
 	//? Make the HTTP request
-	var response ResponseProfanity
-	err := g.getRequest("badWords", &response, payload)
+	response, _, err := do[ResponseProfanity](ctx, g, http.MethodGet, "badWords", payload)
 	if err != nil {
 		return nil, err
 	}
 
-	return &response, err
+	return &response, nil
 }
 
 // Performs an ASN lookup using the Greip API to retrieve details about the specified Autonomous System Number (ASN).
@@ -465,27 +507,28 @@ func (g *Greip) Profanity(text string) (*ResponseProfanity, error) {
 //   - Network-related errors (e.g., timeouts, unreachable server).
 //   - API-related errors (e.g., invalid API token, empty ASN).
 func (g *Greip) AsnLookup(asn string) (*ResponseASN, error) {
+	return g.AsnLookupContext(context.Background(), asn)
+}
+
+// AsnLookupContext is like AsnLookup but uses ctx for the outgoing HTTP request,
+// so cancelling ctx or reaching its deadline aborts the call.
+func (g *Greip) AsnLookupContext(ctx context.Context, asn string) (*ResponseASN, error) {
 	payload := map[string]interface{}{
 		"asn": asn,
 	}
 
 	//? Validate the input ASN
 	if asn == "" {
-		return nil, errors.New("you must provide the `asn` parameter")
+		return nil, missingParamError("asn")
 	}
 
-	//? Construct the query parameters
-	query := url.Values{}
-	query.Set("asn", asn)
-
 	//? Make the HTTP request
-	var response ResponseASN
-	err := g.getRequest("ASNLookup", &response, payload)
+	response, _, err := do[ResponseASN](ctx, g, http.MethodGet, "ASNLookup", payload)
 	if err != nil {
 		return nil, err
 	}
 
-	return &response, err
+	return &response, nil
 }
 
 // Performs an email validation using the Greip API to check the validity of the specified email address.
@@ -520,27 +563,28 @@ func (g *Greip) AsnLookup(asn string) (*ResponseASN, error) {
 //   - Network-related errors (e.g., timeouts, unreachable server).
 //   - API-related errors (e.g., invalid API token, empty email address).
 func (g *Greip) Email(email string) (*ResponseEmail, error) {
+	return g.EmailContext(context.Background(), email)
+}
+
+// EmailContext is like Email but uses ctx for the outgoing HTTP request,
+// so cancelling ctx or reaching its deadline aborts the call.
+func (g *Greip) EmailContext(ctx context.Context, email string) (*ResponseEmail, error) {
 	payload := map[string]interface{}{
 		"email": email,
 	}
 
 	//? Validate the input email
 	if email == "" {
-		return nil, errors.New("you must provide the `email` parameter")
+		return nil, missingParamError("email")
 	}
 
-	//? Construct the query parameters
-	query := url.Values{}
-	query.Set("email", email)
-
 	//? Make the HTTP request
-	var response ResponseEmail
-	err := g.getRequest("validateEmail", &response, payload)
+	response, _, err := do[ResponseEmail](ctx, g, http.MethodGet, "validateEmail", payload)
 	if err != nil {
 		return nil, err
 	}
 
-	return &response, err
+	return &response, nil
 }
 
 // Performs a phone validation using the Greip API to check the validity of the specified phone number.
@@ -576,6 +620,12 @@ func (g *Greip) Email(email string) (*ResponseEmail, error) {
 //   - Network-related errors (e.g., timeouts, unreachable server).
 //   - API-related errors (e.g., invalid API token, empty phone number).
 func (g *Greip) Phone(phone string, countryCode string) (*ResponsePhone, error) {
+	return g.PhoneContext(context.Background(), phone, countryCode)
+}
+
+// PhoneContext is like Phone but uses ctx for the outgoing HTTP request,
+// so cancelling ctx or reaching its deadline aborts the call.
+func (g *Greip) PhoneContext(ctx context.Context, phone string, countryCode string) (*ResponsePhone, error) {
 	payload := map[string]interface{}{
 		"phone":       phone,
 		"countryCode": countryCode,
@@ -583,27 +633,21 @@ func (g *Greip) Phone(phone string, countryCode string) (*ResponsePhone, error)
 
 	//? Validate the input phone
 	if phone == "" {
-		return nil, errors.New("you must provide the `phone` parameter")
+		return nil, missingParamError("phone")
 	}
 
 	//? Validate the input countryCode
 	if countryCode == "" {
-		return nil, errors.New("you must provide the `countryCode` parameter")
+		return nil, missingParamError("countryCode")
 	}
 
-	//? Construct the query parameters
-	query := url.Values{}
-	query.Set("phone", phone)
-	query.Set("countryCode", countryCode)
-
 	//? Make the HTTP request
-	var response ResponsePhone
-	err := g.getRequest("validatePhone", &response, payload)
+	response, _, err := do[ResponsePhone](ctx, g, http.MethodGet, "validatePhone", payload)
 	if err != nil {
 		return nil, err
 	}
 
-	return &response, err
+	return &response, nil
 }
 
 // Performs an IBAN validation & lookup using the Greip API to check the validity of the specified IBAN (International Bank Account Number).
@@ -638,27 +682,28 @@ func (g *Greip) Phone(phone string, countryCode string) (*ResponsePhone, error)
 //   - Network-related errors (e.g., timeouts, unreachable server).
 //   - API-related errors (e.g., invalid API token, empty IBAN).
 func (g *Greip) IBAN(iban string) (*ResponseIBAN, error) {
+	return g.IBANContext(context.Background(), iban)
+}
+
+// IBANContext is like IBAN but uses ctx for the outgoing HTTP request,
+// so cancelling ctx or reaching its deadline aborts the call.
+func (g *Greip) IBANContext(ctx context.Context, iban string) (*ResponseIBAN, error) {
 	payload := map[string]interface{}{
 		"iban": iban,
 	}
 
 	//? Validate the input iban
 	if iban == "" {
-		return nil, errors.New("you must provide the `iban` parameter")
+		return nil, missingParamError("iban")
 	}
 
-	//? Construct the query parameters
-	query := url.Values{}
-	query.Set("iban", iban)
-
 	//? Make the HTTP request
-	var response ResponseIBAN
-	err := g.getRequest("validateIBAN", &response, payload)
+	response, _, err := do[ResponseIBAN](ctx, g, http.MethodGet, "validateIBAN", payload)
 	if err != nil {
 		return nil, err
 	}
 
-	return &response, err
+	return &response, nil
 }
 
 // Performs a payment fraud detection using the Greip API to check the payment data for potential fraud indicators.
@@ -702,22 +747,31 @@ func (g *Greip) IBAN(iban string) (*ResponseIBAN, error) {
 //   - Network-related errors (e.g., timeouts, unreachable server).
 //   - API-related errors (e.g., invalid API token, empty payment data).
 func (g *Greip) Payment(data map[string]interface{}) (*ResponsePayment, error) {
+	return g.PaymentContext(context.Background(), data)
+}
+
+// PaymentContext is like Payment but uses ctx for the outgoing HTTP request,
+// so cancelling ctx or reaching its deadline aborts the call.
+func (g *Greip) PaymentContext(ctx context.Context, data map[string]interface{}) (*ResponsePayment, error) {
 	//? Validate the input data
 	if data == nil {
-		return nil, errors.New("you must provide the `data` parameter")
+		return nil, missingParamError("data")
 	}
 
-	//? Create new variable for the data
+	return g.payment(ctx, data)
+}
+
+// ? Helper function to send the payment data, a map or a PaymentRequest, to the fraud detection endpoint
+func (g *Greip) payment(ctx context.Context, data interface{}) (*ResponsePayment, error) {
 	payload := map[string]interface{}{
 		"data": data,
 	}
 
 	//? Make the HTTP request
-	var response ResponsePayment
-	err := g.postRequest("paymentFraud", &response, payload)
+	response, _, err := do[ResponsePayment](ctx, g, http.MethodPost, "paymentFraud", payload)
 	if err != nil {
 		return nil, err
 	}
 
-	return &response, err
+	return &response, nil
 }
@@ -0,0 +1,140 @@
+package greiphttp
+
+import (
+	"errors"
+	"net/http"
+	"net/netip"
+	"strings"
+)
+
+// ClientIPResolver finds the IP address of the client that sent a request, resisting spoofed
+// forwarding headers: headers are only believed when the request comes from a trusted proxy,
+// and forwarding chains are walked from the closest hop, stopping at the first untrusted one.
+//
+// Example usage:
+//
+//	// Behind Cloudflare, then an internal load balancer
+//	resolver := greiphttp.ClientIPResolver{
+//	    TrustedProxies: []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")},
+//	    Headers:        []string{"CF-Connecting-IP"},
+//	}
+//	ip, err := resolver.ClientIP(r)
+type ClientIPResolver struct {
+	// TrustedProxies are the proxies whose headers are believed. Without any,
+	// the IP address of the client is always the remote address of the request.
+	TrustedProxies []netip.Prefix
+
+	// UseForwarded makes the resolver walk the RFC 7239 Forwarded header instead of
+	// X-Forwarded-For. Only one of them is used, the one set by the trusted proxies,
+	// since clients can send the other one to spoof their address.
+	UseForwarded bool
+
+	// Headers are headers holding a single IP address, set by the trusted proxies or CDN
+	// (e.g. "X-Real-IP", "CF-Connecting-IP", "True-Client-IP", "Fastly-Client-IP").
+	// The first one present with a valid IP address wins over the forwarding chain.
+	// Only list headers that the trusted proxies always overwrite.
+	Headers []string
+}
+
+// ClientIP returns the IP address of the client that sent r, walking the X-Forwarded-For chain
+// from the closest hop as long as the hops belong to the trusted proxies. IPv4-mapped IPv6
+// addresses are unwrapped and zones are stripped.
+func ClientIP(r *http.Request, trusted []netip.Prefix) (netip.Addr, error) {
+	resolver := ClientIPResolver{TrustedProxies: trusted}
+	return resolver.ClientIP(r)
+}
+
+// ClientIP returns the IP address of the client that sent r. It returns an error only if
+// the remote address of the request is invalid.
+func (c *ClientIPResolver) ClientIP(r *http.Request) (netip.Addr, error) {
+	remote, ok := parseHop(r.RemoteAddr)
+	if !ok {
+		return netip.Addr{}, errors.New("greiphttp: invalid remote address: " + r.RemoteAddr)
+	}
+	if !c.trusts(remote) {
+		return remote, nil
+	}
+
+	//? Single-IP headers are set by the edge itself; repeated headers are a sign of spoofing
+	for _, header := range c.Headers {
+		if values := r.Header.Values(header); len(values) == 1 {
+			if addr, ok := parseHop(strings.TrimSpace(values[0])); ok {
+				return addr, nil
+			}
+		}
+	}
+
+	var hops []string
+	if c.UseForwarded {
+		hops = forwardedFor(r.Header.Values("Forwarded"))
+	} else {
+		hops = splitList(r.Header.Values("X-Forwarded-For"))
+	}
+
+	//? Walk the chain from the closest hop, stopping at the first untrusted or invalid one
+	client := remote
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop, ok := parseHop(hops[i])
+		if !ok {
+			break
+		}
+		client = hop
+		if !c.trusts(hop) {
+			break
+		}
+	}
+	return client, nil
+}
+
+// ? Helper function to check whether an IP address belongs to one of the trusted proxies
+func (c *ClientIPResolver) trusts(addr netip.Addr) bool {
+	for _, prefix := range c.TrustedProxies {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// ? Helper function to get the `for` parameters of Forwarded headers, in order
+func forwardedFor(values []string) []string {
+	var hops []string
+	for _, element := range splitList(values) {
+		for _, pair := range strings.Split(element, ";") {
+			key, value, ok := strings.Cut(strings.TrimSpace(pair), "=")
+			if ok && strings.EqualFold(strings.TrimSpace(key), "for") {
+				hops = append(hops, strings.Trim(strings.TrimSpace(value), `"`))
+			}
+		}
+	}
+	return hops
+}
+
+// ? Helper function to split comma-separated header values into trimmed elements
+func splitList(values []string) []string {
+	var elements []string
+	for _, value := range values {
+		for _, element := range strings.Split(value, ",") {
+			if element = strings.TrimSpace(element); element != "" {
+				elements = append(elements, element)
+			}
+		}
+	}
+	return elements
+}
+
+// ? Helper function to parse a hop, which may carry a port and brackets around IPv6 addresses.
+// Obfuscated identifiers and "unknown" (RFC 7239) are rejected.
+func parseHop(hop string) (netip.Addr, bool) {
+	if addrPort, err := netip.ParseAddrPort(hop); err == nil {
+		return addrPort.Addr().Unmap().WithZone(""), true
+	}
+	if strings.HasPrefix(hop, "[") != strings.HasSuffix(hop, "]") {
+		return netip.Addr{}, false
+	}
+	addr, err := netip.ParseAddr(strings.TrimSuffix(strings.TrimPrefix(hop, "["), "]"))
+	if err != nil {
+		return netip.Addr{}, false
+	}
+	return addr.Unmap().WithZone(""), true
+}
@@ -0,0 +1,116 @@
+package greiphttp_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+
+	"github.com/greipio/go/greiphttp"
+	"github.com/greipio/go/greiptest"
+)
+
+func TestClientIPResolver(t *testing.T) {
+	trusted := []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8"), netip.MustParsePrefix("2001:db8:ffff::/48")}
+	xff := greiphttp.ClientIPResolver{TrustedProxies: trusted}
+	forwarded := greiphttp.ClientIPResolver{TrustedProxies: trusted, UseForwarded: true}
+	realIP := greiphttp.ClientIPResolver{TrustedProxies: trusted, Headers: []string{"X-Real-IP"}}
+
+	tests := []struct {
+		name     string
+		resolver greiphttp.ClientIPResolver
+		remote   string
+		header   http.Header
+		want     string
+	}{
+		{"no trusted proxy", greiphttp.ClientIPResolver{}, "1.2.3.4:5678", http.Header{"X-Forwarded-For": {"9.9.9.9"}}, "1.2.3.4"},
+		{"untrusted remote", xff, "1.2.3.4:5678", http.Header{"X-Forwarded-For": {"9.9.9.9"}}, "1.2.3.4"},
+		{"no header", xff, "10.0.0.1:1234", nil, "10.0.0.1"},
+		{"first untrusted hop wins", xff, "10.0.0.1:1234", http.Header{"X-Forwarded-For": {"9.9.9.9, 1.1.1.1, 10.0.0.2"}}, "1.1.1.1"},
+		{"every hop trusted", xff, "10.0.0.1:1234", http.Header{"X-Forwarded-For": {"10.0.0.3, 10.0.0.2"}}, "10.0.0.3"},
+		{"repeated X-Forwarded-For headers", xff, "10.0.0.1:1234", http.Header{"X-Forwarded-For": {"9.9.9.9", "1.1.1.1"}}, "1.1.1.1"},
+		{"invalid hop stops the walk", xff, "10.0.0.1:1234", http.Header{"X-Forwarded-For": {"1.1.1.1, garbage, 10.0.0.2"}}, "10.0.0.2"},
+		{"IPv4 hop with port", xff, "10.0.0.1:1234", http.Header{"X-Forwarded-For": {"1.1.1.1:443"}}, "1.1.1.1"},
+		{"IPv6 hop with port", xff, "10.0.0.1:1234", http.Header{"X-Forwarded-For": {"[2606:4700::1111]:443"}}, "2606:4700::1111"},
+		{"bracketed IPv6 hop", xff, "10.0.0.1:1234", http.Header{"X-Forwarded-For": {"[2606:4700::1111]"}}, "2606:4700::1111"},
+		{"unbalanced bracket", xff, "10.0.0.1:1234", http.Header{"X-Forwarded-For": {"[2606:4700::1111"}}, "10.0.0.1"},
+		{"IPv4-mapped hop", xff, "10.0.0.1:1234", http.Header{"X-Forwarded-For": {"::ffff:1.1.1.1"}}, "1.1.1.1"},
+		{"IPv4-mapped remote", xff, "[::ffff:10.0.0.1]:1234", http.Header{"X-Forwarded-For": {"1.1.1.1"}}, "1.1.1.1"},
+		{"zoned hop", xff, "10.0.0.1:1234", http.Header{"X-Forwarded-For": {"fe80::1%eth0"}}, "fe80::1"},
+		{"trusted IPv6 proxy", xff, "[2001:db8:ffff::1]:443", http.Header{"X-Forwarded-For": {"1.1.1.1"}}, "1.1.1.1"},
+		{"X-Forwarded-For ignores Forwarded", xff, "10.0.0.1:1234", http.Header{"Forwarded": {"for=1.1.1.1"}}, "10.0.0.1"},
+
+		{"Forwarded", forwarded, "10.0.0.1:1234", http.Header{"Forwarded": {`for=9.9.9.9, for=1.1.1.1;proto=https, for=10.0.0.2`}}, "1.1.1.1"},
+		{"Forwarded with quoted IPv6 and port", forwarded, "10.0.0.1:1234", http.Header{"Forwarded": {`for="[2606:4700::1111]:4711";by=10.0.0.1`}}, "2606:4700::1111"},
+		{"Forwarded with case-insensitive key", forwarded, "10.0.0.1:1234", http.Header{"Forwarded": {"proto=https;For=1.1.1.1"}}, "1.1.1.1"},
+		{"Forwarded unknown", forwarded, "10.0.0.1:1234", http.Header{"Forwarded": {"for=unknown"}}, "10.0.0.1"},
+		{"Forwarded obfuscated", forwarded, "10.0.0.1:1234", http.Header{"Forwarded": {"for=1.1.1.1, for=_hidden, for=10.0.0.2"}}, "10.0.0.2"},
+		{"Forwarded ignores X-Forwarded-For", forwarded, "10.0.0.1:1234", http.Header{"Forwarded": {"for=1.1.1.1"}, "X-Forwarded-For": {"9.9.9.9"}}, "1.1.1.1"},
+
+		{"single-IP header", realIP, "10.0.0.1:1234", http.Header{"X-Real-Ip": {"1.1.1.1"}, "X-Forwarded-For": {"9.9.9.9"}}, "1.1.1.1"},
+		{"single-IP header from an untrusted remote", realIP, "1.2.3.4:5678", http.Header{"X-Real-Ip": {"1.1.1.1"}}, "1.2.3.4"},
+		{"repeated single-IP header", realIP, "10.0.0.1:1234", http.Header{"X-Real-Ip": {"1.1.1.1", "6.6.6.6"}, "X-Forwarded-For": {"9.9.9.9"}}, "9.9.9.9"},
+		{"invalid single-IP header", realIP, "10.0.0.1:1234", http.Header{"X-Real-Ip": {"unknown"}}, "10.0.0.1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.RemoteAddr = tt.remote
+			r.Header = tt.header
+
+			got, err := tt.resolver.ClientIP(r)
+			if err != nil {
+				t.Fatalf("ClientIP: %v", err)
+			}
+			if got != netip.MustParseAddr(tt.want) {
+				t.Fatalf("got %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClientIPInvalidRemoteAddr(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.RemoteAddr = "@"
+	if got, err := greiphttp.ClientIP(r, nil); err == nil {
+		t.Fatalf("got %s, want an error", got)
+	}
+}
+
+func TestTrustedProxiesIgnoreForwarded(t *testing.T) {
+	tests := []struct {
+		name   string
+		header http.Header
+		want   string
+	}{
+		{"X-Forwarded-For", http.Header{"X-Forwarded-For": {"1.1.1.1"}}, "1.1.1.1"},
+		{"Forwarded", http.Header{"Forwarded": {"for=1.1.1.1"}}, "8.8.8.8"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := greiptest.NewServer()
+			defer server.Close()
+
+			var got netip.Addr
+			handler := greiphttp.Middleware(server.Client(),
+				greiphttp.WithTrustedProxies(netip.MustParsePrefix("8.8.8.0/24")),
+			)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if info, ok := greiphttp.FromContext(r.Context()); ok && info.Err == nil {
+					got = info.IP
+				}
+			}))
+
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.RemoteAddr = "8.8.8.8:1234"
+			r.Header = tt.header
+			handler.ServeHTTP(httptest.NewRecorder(), r)
+
+			if got != netip.MustParseAddr(tt.want) {
+				t.Fatalf("got client IP %s, want %s", got, tt.want)
+			}
+			if requests := server.RequestsTo("IPLookup"); len(requests) != 1 || requests[0].Query.Get("ip") != tt.want {
+				t.Fatalf("got requests %+v, want a lookup of %s", requests, tt.want)
+			}
+		})
+	}
+}
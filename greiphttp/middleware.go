@@ -0,0 +1,297 @@
+// Package greiphttp enriches incoming net/http requests with Greip IP intelligence.
+//
+// Middleware finds the IP address of the client, looks it up with the Greip client and stores
+// the result in the request context, where handlers get it back with FromContext. Policies can
+// block requests from Tor exit nodes, proxies, hosting providers or given countries.
+//
+// The middleware fails open: requests whose client IP address can't be found or looked up,
+// for instance because the Greip API is unreachable, reach the next handler with Info.Err set
+// and no policy applied. Use WithFailClosed to block them instead, or WithErrorHandler to
+// handle them in any other way.
+//
+// ClientIPResolver, which finds the IP address of the client behind trusted proxies,
+// can also be used on its own.
+//
+// Example usage:
+//
+//	client := greip.NewClient("YOUR_API_TOKEN", greip.WithCache(greip.NewLRUCache(10000)))
+//	handler := greiphttp.Middleware(client,
+//	    greiphttp.WithTrustedProxies(netip.MustParsePrefix("10.0.0.0/8")),
+//	    greiphttp.WithPolicy(greiphttp.BlockTor()),
+//	    greiphttp.WithPolicy(greiphttp.BlockCountries("KP", "IR")),
+//	)(mux)
+//
+//	func handle(w http.ResponseWriter, r *http.Request) {
+//	    if info, ok := greiphttp.FromContext(r.Context()); ok && info.Lookup != nil {
+//	        fmt.Fprintln(w, info.Lookup.CountryCode)
+//	    }
+//	}
+package greiphttp
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/netip"
+	"strings"
+	"time"
+
+	greip "github.com/greipio/go"
+)
+
+// Info is the IP intelligence attached to a request by Middleware.
+type Info struct {
+	// IP is the IP address of the client.
+	IP netip.Addr
+	// Lookup is the result of Lookup, unless the middleware uses Threats or the call failed.
+	Lookup *greip.ResponseLookup
+	// Threats is the result of Threats, when the middleware uses it instead of Lookup.
+	Threats *greip.ResponseThreats
+	// Err is the reason why the IP address of the client could not be found or looked up.
+	Err error
+	// BlockReason is the reason given by the policy that blocked the request, if any.
+	BlockReason string
+}
+
+// Security returns the security flags of the client IP address, from either Lookup or Threats.
+// Lookup only reports them when the "security" param is requested, which is the default.
+func (i *Info) Security() greip.LookupSecurity {
+	switch {
+	case i.Lookup != nil:
+		return i.Lookup.Security
+	case i.Threats != nil:
+		t := i.Threats.Threats
+		return greip.LookupSecurity{
+			IsProxy:   t.IsProxy,
+			ProxyType: t.ProxyType,
+			IsTor:     t.IsTor,
+			IsBot:     t.IsBot,
+			IsRelay:   t.IsRelay,
+			IsHosting: t.IsHosting,
+		}
+	}
+	return greip.LookupSecurity{}
+}
+
+type contextKey struct{}
+
+// FromContext returns the Info stored in ctx by Middleware.
+func FromContext(ctx context.Context) (*Info, bool) {
+	info, ok := ctx.Value(contextKey{}).(*Info)
+	return info, ok
+}
+
+// Policy decides whether a request must be blocked, given the IP intelligence of its client.
+// It is only called when the lookup succeeded.
+type Policy func(info *Info) (reason string, block bool)
+
+// BlockTor blocks Tor exit nodes.
+func BlockTor() Policy {
+	return func(info *Info) (string, bool) {
+		return "tor", info.Security().IsTor
+	}
+}
+
+// BlockProxies blocks proxies and VPNs.
+func BlockProxies() Policy {
+	return func(info *Info) (string, bool) {
+		return "proxy", info.Security().IsProxy
+	}
+}
+
+// BlockHosting blocks IP addresses of hosting and cloud providers.
+func BlockHosting() Policy {
+	return func(info *Info) (string, bool) {
+		return "hosting", info.Security().IsHosting
+	}
+}
+
+// BlockCountries blocks the given ISO 3166-1 alpha-2 country codes. It requires Lookup,
+// and never blocks when the middleware uses Threats.
+func BlockCountries(codes ...string) Policy {
+	blocked := make(map[string]bool, len(codes))
+	for _, code := range codes {
+		blocked[strings.ToUpper(code)] = true
+	}
+	return func(info *Info) (string, bool) {
+		if info.Lookup == nil {
+			return "", false
+		}
+		code := strings.ToUpper(info.Lookup.CountryCode)
+		return "country " + code, blocked[code]
+	}
+}
+
+// Option configures the middleware.
+type Option func(*config)
+
+type config struct {
+	resolver     ClientIPResolver
+	params       []string
+	lang         []string
+	threats      bool
+	cache        greip.Cache
+	cacheTTL     time.Duration
+	policies     []Policy
+	blockHandler http.Handler
+	errorHandler func(w http.ResponseWriter, r *http.Request, err error)
+	failClosed   bool
+}
+
+// WithTrustedProxies adds proxies whose X-Forwarded-For header is believed. Without any,
+// the IP address of the client is always the remote address.
+func WithTrustedProxies(prefixes ...netip.Prefix) Option {
+	return func(c *config) {
+		c.resolver.TrustedProxies = append(c.resolver.TrustedProxies, prefixes...)
+	}
+}
+
+// WithClientIPResolver sets how the IP address of the client is found, for deployments
+// relying on the Forwarded header, X-Real-IP or CDN headers. It replaces the proxies set
+// by previous WithTrustedProxies options.
+func WithClientIPResolver(resolver ClientIPResolver) Option {
+	return func(c *config) {
+		c.resolver = resolver
+	}
+}
+
+// WithLookupParams sets the params and, optionally, the language of the Lookup call
+// (only "security" by default).
+func WithLookupParams(params []string, lang ...string) Option {
+	return func(c *config) {
+		c.params, c.lang = params, lang
+	}
+}
+
+// WithThreats makes the middleware call Threats instead of Lookup, which only reports
+// the security flags of the IP address.
+func WithThreats() Option {
+	return func(c *config) {
+		c.threats = true
+	}
+}
+
+// WithCache caches the results in cache for ttl, on top of any cache configured on the client.
+// It is mostly useful with clients other than *greip.Greip.
+func WithCache(cache greip.Cache, ttl time.Duration) Option {
+	return func(c *config) {
+		c.cache, c.cacheTTL = cache, ttl
+	}
+}
+
+// WithPolicy adds a policy. Policies are evaluated in order, and the first one blocking
+// the request wins.
+func WithPolicy(policy Policy) Option {
+	return func(c *config) {
+		c.policies = append(c.policies, policy)
+	}
+}
+
+// WithBlockHandler sets the handler serving blocked requests, which replies
+// 403 Forbidden by default. The Info of the request, with its BlockReason, is available
+// through FromContext.
+func WithBlockHandler(handler http.Handler) Option {
+	return func(c *config) {
+		c.blockHandler = handler
+	}
+}
+
+// WithErrorHandler sets the handler serving requests whose client IP address could not be
+// found or looked up. By default, such requests are passed on to the next handler with
+// Info.Err set, and no policy applies to them (see WithFailClosed).
+func WithErrorHandler(handler func(w http.ResponseWriter, r *http.Request, err error)) Option {
+	return func(c *config) {
+		c.errorHandler = handler
+	}
+}
+
+// WithFailClosed makes the middleware block the requests whose client IP address could not be
+// found or looked up, with the block handler and a BlockReason of "lookup failed", instead of
+// passing them on. WithErrorHandler takes precedence over it.
+func WithFailClosed() Option {
+	return func(c *config) {
+		c.failClosed = true
+	}
+}
+
+// Middleware returns a net/http middleware looking up the IP address of the client of every
+// request with client, and storing the result in the request context.
+func Middleware(client greip.Client, options ...Option) func(http.Handler) http.Handler {
+	cfg := config{params: []string{"security"}}
+	for _, option := range options {
+		option(&cfg)
+	}
+	if cfg.blockHandler == nil {
+		cfg.blockHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+		})
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			info := &Info{}
+			r = r.WithContext(context.WithValue(r.Context(), contextKey{}, info))
+
+			info.IP, info.Err = cfg.resolver.ClientIP(r)
+			if info.Err == nil {
+				info.Err = cfg.lookup(r.Context(), client, info)
+			}
+
+			if info.Err != nil {
+				switch {
+				case cfg.errorHandler != nil:
+					cfg.errorHandler(w, r, info.Err)
+				case cfg.failClosed:
+					info.BlockReason = "lookup failed"
+					cfg.blockHandler.ServeHTTP(w, r)
+				default:
+					next.ServeHTTP(w, r)
+				}
+				return
+			}
+
+			for _, policy := range cfg.policies {
+				if reason, block := policy(info); block {
+					info.BlockReason = reason
+					cfg.blockHandler.ServeHTTP(w, r)
+					return
+				}
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// ? Helper function to look up the IP address of the client, going through the cache if any
+func (c *config) lookup(ctx context.Context, client greip.Client, info *Info) error {
+	ip := info.IP.String()
+	key := "greiphttp:IPLookup:" + strings.Join(c.params, ",") + ":" + strings.Join(c.lang, ",") + ":" + ip
+	target := interface{}(&info.Lookup)
+	if c.threats {
+		key = "greiphttp:threats:" + ip
+		target = &info.Threats
+	}
+
+	if c.cache != nil {
+		if data, ok := c.cache.Get(key); ok && json.Unmarshal(data, target) == nil {
+			return nil
+		}
+	}
+
+	var err error
+	if c.threats {
+		info.Threats, err = client.ThreatsContext(ctx, ip)
+	} else {
+		info.Lookup, err = client.LookupContext(ctx, ip, c.params, c.lang...)
+	}
+	if err != nil {
+		return err
+	}
+
+	if c.cache != nil && c.cacheTTL > 0 {
+		if data, err := json.Marshal(target); err == nil {
+			c.cache.Set(key, data, c.cacheTTL)
+		}
+	}
+	return nil
+}
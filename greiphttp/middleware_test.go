@@ -0,0 +1,206 @@
+package greiphttp_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	greip "github.com/greipio/go"
+	"github.com/greipio/go/greiphttp"
+	"github.com/greipio/go/greiptest"
+)
+
+func TestMiddlewarePolicies(t *testing.T) {
+	lookup := func(security greip.LookupSecurity, country string) *greiptest.FakeClient {
+		return &greiptest.FakeClient{
+			LookupFunc: func(ctx context.Context, ip string, params []string, lang ...string) (*greip.ResponseLookup, error) {
+				return &greip.ResponseLookup{IP: ip, CountryCode: country, Security: security}, nil
+			},
+		}
+	}
+	threats := &greiptest.FakeClient{
+		ThreatsFunc: func(ctx context.Context, ip string) (*greip.ResponseThreats, error) {
+			return &greip.ResponseThreats{IP: ip, Threats: greip.Threats{IsTor: true}}, nil
+		},
+	}
+
+	tests := []struct {
+		name    string
+		client  *greiptest.FakeClient
+		options []greiphttp.Option
+		reason  string
+	}{
+		{"no policy", lookup(greip.LookupSecurity{IsTor: true}, "KP"), nil, ""},
+		{"Tor", lookup(greip.LookupSecurity{IsTor: true}, "FR"), []greiphttp.Option{greiphttp.WithPolicy(greiphttp.BlockTor())}, "tor"},
+		{"not Tor", lookup(greip.LookupSecurity{IsProxy: true}, "FR"), []greiphttp.Option{greiphttp.WithPolicy(greiphttp.BlockTor())}, ""},
+		{"proxy", lookup(greip.LookupSecurity{IsProxy: true}, "FR"), []greiphttp.Option{greiphttp.WithPolicy(greiphttp.BlockProxies())}, "proxy"},
+		{"hosting", lookup(greip.LookupSecurity{IsHosting: true}, "FR"), []greiphttp.Option{greiphttp.WithPolicy(greiphttp.BlockHosting())}, "hosting"},
+		{"country", lookup(greip.LookupSecurity{}, "kp"), []greiphttp.Option{greiphttp.WithPolicy(greiphttp.BlockCountries("IR", "KP"))}, "country KP"},
+		{"other country", lookup(greip.LookupSecurity{}, "FR"), []greiphttp.Option{greiphttp.WithPolicy(greiphttp.BlockCountries("IR", "KP"))}, ""},
+		{"first policy wins", lookup(greip.LookupSecurity{IsTor: true, IsHosting: true}, "FR"), []greiphttp.Option{
+			greiphttp.WithPolicy(greiphttp.BlockHosting()),
+			greiphttp.WithPolicy(greiphttp.BlockTor()),
+		}, "hosting"},
+		{"Tor with threats", threats, []greiphttp.Option{greiphttp.WithThreats(), greiphttp.WithPolicy(greiphttp.BlockTor())}, "tor"},
+		{"country with threats", threats, []greiphttp.Option{greiphttp.WithThreats(), greiphttp.WithPolicy(greiphttp.BlockCountries("KP"))}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var served *greiphttp.Info
+			handler := greiphttp.Middleware(tt.client, tt.options...)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				served, _ = greiphttp.FromContext(r.Context())
+			}))
+
+			recorder := httptest.NewRecorder()
+			handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/", nil))
+
+			if tt.reason != "" {
+				if recorder.Code != http.StatusForbidden || served != nil {
+					t.Fatalf("got status %d, want the request to be blocked with a 403", recorder.Code)
+				}
+				return
+			}
+			if recorder.Code != http.StatusOK || served == nil || served.Err != nil || served.IP.String() != "192.0.2.1" {
+				t.Fatalf("got status %d and info %+v, want the request to be served", recorder.Code, served)
+			}
+		})
+	}
+}
+
+func TestMiddlewareBlockHandler(t *testing.T) {
+	client := &greiptest.FakeClient{
+		LookupFunc: func(ctx context.Context, ip string, params []string, lang ...string) (*greip.ResponseLookup, error) {
+			return &greip.ResponseLookup{IP: ip, CountryCode: "KP"}, nil
+		},
+	}
+	handler := greiphttp.Middleware(client,
+		greiphttp.WithPolicy(greiphttp.BlockCountries("KP")),
+		greiphttp.WithBlockHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			info, _ := greiphttp.FromContext(r.Context())
+			http.Error(w, info.BlockReason, http.StatusUnavailableForLegalReasons)
+		})),
+	)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Fatal("blocked request reached the next handler")
+	}))
+
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/", nil))
+	if recorder.Code != http.StatusUnavailableForLegalReasons || recorder.Body.String() != "country KP\n" {
+		t.Fatalf("got %d %q, want the custom block handler to reply", recorder.Code, recorder.Body.String())
+	}
+}
+
+func TestMiddlewareLookupError(t *testing.T) {
+	errLookup := errors.New("API unreachable")
+	failing := &greiptest.FakeClient{
+		LookupFunc: func(ctx context.Context, ip string, params []string, lang ...string) (*greip.ResponseLookup, error) {
+			return nil, errLookup
+		},
+	}
+
+	tests := []struct {
+		name    string
+		remote  string
+		options []greiphttp.Option
+		status  int
+		served  bool
+	}{
+		{"fails open by default", "192.0.2.1:1234", []greiphttp.Option{greiphttp.WithPolicy(greiphttp.BlockTor())}, http.StatusOK, true},
+		{"fails closed", "192.0.2.1:1234", []greiphttp.Option{greiphttp.WithFailClosed()}, http.StatusForbidden, false},
+		{"invalid remote address fails closed", "garbage", []greiphttp.Option{greiphttp.WithFailClosed()}, http.StatusForbidden, false},
+		{"error handler", "192.0.2.1:1234", []greiphttp.Option{
+			greiphttp.WithErrorHandler(func(w http.ResponseWriter, r *http.Request, err error) {
+				if !errors.Is(err, errLookup) {
+					t.Errorf("got error %v, want %v", err, errLookup)
+				}
+				w.WriteHeader(http.StatusServiceUnavailable)
+			}),
+		}, http.StatusServiceUnavailable, false},
+		{"error handler over fail closed", "192.0.2.1:1234", []greiphttp.Option{
+			greiphttp.WithFailClosed(),
+			greiphttp.WithErrorHandler(func(w http.ResponseWriter, r *http.Request, err error) {
+				w.WriteHeader(http.StatusServiceUnavailable)
+			}),
+		}, http.StatusServiceUnavailable, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var served *greiphttp.Info
+			blocked := ""
+			handler := greiphttp.Middleware(failing, append(tt.options, greiphttp.WithBlockHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				info, _ := greiphttp.FromContext(r.Context())
+				blocked = info.BlockReason
+				w.WriteHeader(http.StatusForbidden)
+			})))...)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				served, _ = greiphttp.FromContext(r.Context())
+			}))
+
+			request := httptest.NewRequest(http.MethodGet, "/", nil)
+			request.RemoteAddr = tt.remote
+			recorder := httptest.NewRecorder()
+			handler.ServeHTTP(recorder, request)
+
+			if recorder.Code != tt.status || (served != nil) != tt.served {
+				t.Fatalf("got status %d and info %+v, want %d and served %v", recorder.Code, served, tt.status, tt.served)
+			}
+			if served != nil && served.Err == nil {
+				t.Fatal("got a served request without Info.Err")
+			}
+			if tt.status == http.StatusForbidden && blocked != "lookup failed" {
+				t.Fatalf("got block reason %q, want \"lookup failed\"", blocked)
+			}
+		})
+	}
+}
+
+func TestMiddlewareCache(t *testing.T) {
+	client := &greiptest.FakeClient{
+		LookupFunc: func(ctx context.Context, ip string, params []string, lang ...string) (*greip.ResponseLookup, error) {
+			return &greip.ResponseLookup{IP: ip, CountryCode: "FR", Security: greip.LookupSecurity{IsProxy: true}}, nil
+		},
+		ThreatsFunc: func(ctx context.Context, ip string) (*greip.ResponseThreats, error) {
+			return &greip.ResponseThreats{IP: ip, Threats: greip.Threats{IsProxy: true}}, nil
+		},
+	}
+	cache := greip.NewLRUCache(10)
+
+	serve := func(remote string, options ...greiphttp.Option) *greiphttp.Info {
+		var served *greiphttp.Info
+		options = append([]greiphttp.Option{greiphttp.WithCache(cache, time.Minute)}, options...)
+		handler := greiphttp.Middleware(client, options...)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			served, _ = greiphttp.FromContext(r.Context())
+		}))
+		request := httptest.NewRequest(http.MethodGet, "/", nil)
+		request.RemoteAddr = remote
+		handler.ServeHTTP(httptest.NewRecorder(), request)
+		return served
+	}
+
+	for i := 0; i < 3; i++ {
+		info := serve("192.0.2.1:1234")
+		if info.Lookup == nil || info.Lookup.CountryCode != "FR" || !info.Security().IsProxy {
+			t.Fatalf("got info %+v on request %d, want the lookup", info, i)
+		}
+	}
+	if calls := client.CallsTo("Lookup"); len(calls) != 1 {
+		t.Fatalf("got %d Lookup calls, want the cached lookup to be reused", len(calls))
+	}
+
+	//? Other IP addresses, params and endpoints don't share the cached entry
+	serve("192.0.2.2:1234")
+	serve("192.0.2.1:1234", greiphttp.WithLookupParams([]string{"security", "location"}))
+	if calls := client.CallsTo("Lookup"); len(calls) != 3 {
+		t.Fatalf("got %d Lookup calls, want 3", len(calls))
+	}
+	for i := 0; i < 2; i++ {
+		if info := serve("192.0.2.1:1234", greiphttp.WithThreats()); info.Threats == nil || !info.Security().IsProxy {
+			t.Fatalf("got info %+v, want the threats", info)
+		}
+	}
+	if calls := client.CallsTo("Threats"); len(calls) != 1 {
+		t.Fatalf("got %d Threats calls, want 1", len(calls))
+	}
+}
@@ -0,0 +1,234 @@
+// Package greipotel instruments the Greip client with OpenTelemetry tracing and metrics.
+//
+// Every call to the Greip API gets a client span named after its endpoint (e.g. "greip.IPLookup"),
+// and is recorded by the following instruments:
+//
+//   - greip.client.requests: number of requests, by endpoint, HTTP status code and error type.
+//   - greip.client.errors: number of failed requests, by endpoint and error type.
+//   - greip.client.duration: latency histogram of the requests, in seconds, by endpoint.
+//
+// The preferred way to use it is Middleware, which sees the errors reported by the API in the body
+// of 2xx responses. NewTransport instruments the HTTP transport instead, for clients configured with
+// their own http.Client.
+//
+// Example usage:
+//
+//	greipInstance := greip.NewClient("YOUR_API_TOKEN",
+//	    greip.WithMiddleware(greipotel.Middleware(
+//	        greipotel.WithTracerProvider(tracerProvider),
+//	        greipotel.WithMeterProvider(meterProvider),
+//	    )),
+//	)
+package greipotel
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"path"
+	"time"
+
+	greip "github.com/greipio/go"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/metric/noop"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ScopeName is the instrumentation scope name of the tracer and the meter.
+const ScopeName = "github.com/greipio/go/greipotel"
+
+// Attribute keys specific to the Greip client.
+const (
+	EndpointKey = attribute.Key("greip.endpoint")
+	AttemptKey  = attribute.Key("greip.attempt")
+)
+
+// Option configures the instrumentation.
+type Option func(*config)
+
+type config struct {
+	tracerProvider trace.TracerProvider
+	meterProvider  metric.MeterProvider
+}
+
+// WithTracerProvider sets the tracer provider used to create spans. The global one is used by default.
+func WithTracerProvider(provider trace.TracerProvider) Option {
+	return func(c *config) {
+		c.tracerProvider = provider
+	}
+}
+
+// WithMeterProvider sets the meter provider used to create instruments. The global one is used by default.
+func WithMeterProvider(provider metric.MeterProvider) Option {
+	return func(c *config) {
+		c.meterProvider = provider
+	}
+}
+
+// Middleware returns a greip.Middleware tracing and measuring every call to the Greip API.
+// Errors creating the instruments are reported to otel.Handle, and leave the affected metrics unrecorded.
+func Middleware(options ...Option) greip.Middleware {
+	inst := newInstrumentation(options)
+	return func(next greip.RoundTripFunc) greip.RoundTripFunc {
+		return func(call *greip.Call) (*http.Response, error) {
+			var resp *http.Response
+			var err error
+			inst.observe(call.Request.Context(), call.Endpoint, call.Method, call.Attempt, func(ctx context.Context) (*http.Response, error) {
+				call.Request = call.Request.WithContext(ctx)
+				resp, err = next(call)
+				return resp, err
+			})
+			return resp, err
+		}
+	}
+}
+
+// NewTransport returns an http.RoundTripper tracing and measuring every request sent through base,
+// or http.DefaultTransport if nil. It is meant to be passed to greip.WithTransport, and derives the
+// endpoint name from the request URL. Errors creating the instruments are reported to otel.Handle.
+func NewTransport(base http.RoundTripper, options ...Option) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &transport{base: base, inst: newInstrumentation(options)}
+}
+
+type transport struct {
+	base http.RoundTripper
+	inst *instrumentation
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var resp *http.Response
+	var err error
+	t.inst.observe(req.Context(), path.Base(req.URL.Path), req.Method, 0, func(ctx context.Context) (*http.Response, error) {
+		resp, err = t.base.RoundTrip(req.WithContext(ctx))
+		return resp, err
+	})
+	return resp, err
+}
+
+type instrumentation struct {
+	tracer   trace.Tracer
+	requests metric.Int64Counter
+	errors   metric.Int64Counter
+	duration metric.Float64Histogram
+}
+
+func newInstrumentation(options []Option) *instrumentation {
+	cfg := config{}
+	for _, option := range options {
+		option(&cfg)
+	}
+	if cfg.tracerProvider == nil {
+		cfg.tracerProvider = otel.GetTracerProvider()
+	}
+	if cfg.meterProvider == nil {
+		cfg.meterProvider = otel.GetMeterProvider()
+	}
+
+	meter := cfg.meterProvider.Meter(ScopeName)
+	inst := &instrumentation{tracer: cfg.tracerProvider.Tracer(ScopeName)}
+
+	requests, err := meter.Int64Counter("greip.client.requests",
+		metric.WithDescription("Number of requests sent to the Greip API."),
+		metric.WithUnit("{request}"))
+	inst.requests = instrument[metric.Int64Counter](requests, err, noop.Int64Counter{})
+	errorCount, err := meter.Int64Counter("greip.client.errors",
+		metric.WithDescription("Number of failed requests sent to the Greip API."),
+		metric.WithUnit("{request}"))
+	inst.errors = instrument[metric.Int64Counter](errorCount, err, noop.Int64Counter{})
+	duration, err := meter.Float64Histogram("greip.client.duration",
+		metric.WithDescription("Duration of the requests sent to the Greip API."),
+		metric.WithUnit("s"))
+	inst.duration = instrument[metric.Float64Histogram](duration, err, noop.Float64Histogram{})
+	return inst
+}
+
+// ? Helper function to report an instrument creation error to OpenTelemetry, keeping the instrument
+// returned along with it, or a no-op one if none was
+func instrument[T comparable](created T, err error, fallback T) T {
+	if err != nil {
+		otel.Handle(fmt.Errorf("greipotel: cannot create instrument: %w", err))
+	}
+	var zero T
+	if created == zero {
+		return fallback
+	}
+	return created
+}
+
+// ? Helper function to run a request inside a span, and record its metrics
+func (inst *instrumentation) observe(ctx context.Context, endpoint, method string, attempt int, fn func(ctx context.Context) (*http.Response, error)) {
+	attrs := []attribute.KeyValue{
+		EndpointKey.String(endpoint),
+		semconv.HTTPRequestMethodKey.String(method),
+	}
+	spanAttrs := attrs
+	if attempt > 0 {
+		spanAttrs = append(spanAttrs, AttemptKey.Int(attempt))
+	}
+
+	ctx, span := inst.tracer.Start(ctx, "greip."+endpoint,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(spanAttrs...))
+	defer span.End()
+
+	start := time.Now()
+	resp, err := fn(ctx)
+	elapsed := time.Since(start).Seconds()
+
+	if resp != nil {
+		status := semconv.HTTPResponseStatusCode(resp.StatusCode)
+		attrs = append(attrs, status)
+		span.SetAttributes(status)
+	}
+	if errorType := errorType(resp, err); errorType != "" {
+		errAttr := semconv.ErrorTypeKey.String(errorType)
+		attrs = append(attrs, errAttr)
+		span.SetAttributes(errAttr)
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		} else {
+			span.SetStatus(codes.Error, resp.Status)
+		}
+		inst.errors.Add(ctx, 1, metric.WithAttributes(attrs...))
+	}
+
+	inst.requests.Add(ctx, 1, metric.WithAttributes(attrs...))
+	inst.duration.Record(ctx, elapsed, metric.WithAttributes(attrs...))
+}
+
+// ? Helper function to classify a failure as the value of the error.type attribute, empty on success
+func errorType(resp *http.Response, err error) string {
+	switch {
+	case err == nil && resp != nil && resp.StatusCode >= 400:
+		return fmt.Sprint(resp.StatusCode)
+	case err == nil:
+		return ""
+	case errors.Is(err, greip.ErrUnauthorized):
+		return "unauthorized"
+	case errors.Is(err, greip.ErrQuotaExceeded):
+		return "quota_exceeded"
+	case errors.Is(err, greip.ErrRateLimited):
+		return "rate_limited"
+	case errors.Is(err, greip.ErrInvalidInput):
+		return "invalid_input"
+	case errors.Is(err, context.DeadlineExceeded):
+		return "timeout"
+	case errors.Is(err, context.Canceled):
+		return "canceled"
+	}
+
+	var apiErr *greip.APIError
+	if errors.As(err, &apiErr) {
+		return fmt.Sprint(apiErr.StatusCode)
+	}
+	return fmt.Sprintf("%T", err)
+}
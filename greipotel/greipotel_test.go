@@ -0,0 +1,222 @@
+package greipotel_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	greip "github.com/greipio/go"
+	"github.com/greipio/go/greipotel"
+	"github.com/greipio/go/greiptest"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/metric/noop"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestMiddleware(t *testing.T) {
+	tests := []struct {
+		name      string
+		failure   *greiptest.Failure
+		status    int64
+		errorType string
+	}{
+		{"success", nil, http.StatusOK, ""},
+		{"unauthorized", &greiptest.Failure{StatusCode: http.StatusUnauthorized, Description: "Invalid API key."}, http.StatusUnauthorized, "unauthorized"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := greiptest.NewServer()
+			defer server.Close()
+			if tt.failure != nil {
+				server.Fail("IPLookup", *tt.failure)
+			}
+			exporter, tracerProvider, reader, meterProvider := providers()
+			client := server.Client(greip.WithMiddleware(greipotel.Middleware(
+				greipotel.WithTracerProvider(tracerProvider),
+				greipotel.WithMeterProvider(meterProvider),
+			)))
+
+			_, err := client.Lookup("1.1.1.1", nil)
+			if (err != nil) != (tt.failure != nil) {
+				t.Fatalf("Lookup: %v", err)
+			}
+
+			spans := exporter.GetSpans()
+			if len(spans) != 1 || spans[0].Name != "greip.IPLookup" {
+				t.Fatalf("got spans %+v, want one greip.IPLookup span", spans)
+			}
+			want := map[attribute.Key]attribute.Value{
+				greipotel.EndpointKey:       attribute.StringValue("IPLookup"),
+				"http.request.method":       attribute.StringValue(http.MethodGet),
+				"http.response.status_code": attribute.Int64Value(tt.status),
+				greipotel.AttemptKey:        attribute.Int64Value(1),
+			}
+			if tt.errorType != "" {
+				want["error.type"] = attribute.StringValue(tt.errorType)
+			}
+			checkAttributes(t, attribute.NewSet(spans[0].Attributes...), want)
+			if failed := spans[0].Status.Code == codes.Error; failed != (tt.errorType != "") {
+				t.Fatalf("got span status %+v", spans[0].Status)
+			}
+
+			delete(want, greipotel.AttemptKey)
+			metrics := collect(t, reader)
+			requests := sumPoints(t, metrics, "greip.client.requests")
+			if len(requests) != 1 || requests[0].Value != 1 {
+				t.Fatalf("got request points %+v, want one request", requests)
+			}
+			checkAttributes(t, requests[0].Attributes, want)
+			if duration := histogramPoints(t, metrics, "greip.client.duration"); len(duration) != 1 || duration[0].Count != 1 {
+				t.Fatalf("got duration points %+v, want one measurement", duration)
+			}
+			errorCount := 0
+			for _, point := range sumPoints(t, metrics, "greip.client.errors") {
+				errorCount += int(point.Value)
+			}
+			if want := map[bool]int{true: 1}[tt.errorType != ""]; errorCount != want {
+				t.Fatalf("got %d errors recorded, want %d", errorCount, want)
+			}
+		})
+	}
+}
+
+func TestNewTransport(t *testing.T) {
+	server := greiptest.NewServer()
+	defer server.Close()
+	exporter, tracerProvider, reader, meterProvider := providers()
+	transport := greipotel.NewTransport(nil,
+		greipotel.WithTracerProvider(tracerProvider),
+		greipotel.WithMeterProvider(meterProvider),
+	)
+	client := greip.NewClient(greiptest.Token, greip.WithBaseURL(server.URL), greip.WithTransport(transport))
+
+	if _, err := client.Threats("1.1.1.1"); err != nil {
+		t.Fatalf("Threats: %v", err)
+	}
+
+	//? The endpoint name comes from the URL path, as the transport doesn't see the call
+	spans := exporter.GetSpans()
+	if len(spans) != 1 || spans[0].Name != "greip.threats" {
+		t.Fatalf("got spans %+v, want one greip.threats span", spans)
+	}
+	requests := sumPoints(t, collect(t, reader), "greip.client.requests")
+	if len(requests) != 1 {
+		t.Fatalf("got request points %+v, want one request", requests)
+	}
+	checkAttributes(t, requests[0].Attributes, map[attribute.Key]attribute.Value{
+		greipotel.EndpointKey:       attribute.StringValue("threats"),
+		"http.response.status_code": attribute.Int64Value(http.StatusOK),
+	})
+}
+
+func TestInstrumentErrorsAreHandled(t *testing.T) {
+	var handled []error
+	previous := otel.GetErrorHandler()
+	otel.SetErrorHandler(otel.ErrorHandlerFunc(func(err error) { handled = append(handled, err) }))
+	defer otel.SetErrorHandler(previous)
+
+	server := greiptest.NewServer()
+	defer server.Close()
+	client := server.Client(greip.WithMiddleware(greipotel.Middleware(
+		greipotel.WithMeterProvider(failingMeterProvider{}),
+	)))
+
+	if _, err := client.Lookup("1.1.1.1", nil); err != nil {
+		t.Fatalf("Lookup: %v", err)
+	}
+	if len(handled) != 3 {
+		t.Fatalf("got handled errors %v, want one per instrument", handled)
+	}
+}
+
+// ? Helper function to create in-memory tracer and meter providers
+func providers() (*tracetest.InMemoryExporter, *sdktrace.TracerProvider, *sdkmetric.ManualReader, *sdkmetric.MeterProvider) {
+	exporter := tracetest.NewInMemoryExporter()
+	reader := sdkmetric.NewManualReader()
+	return exporter, sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter)),
+		reader, sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+}
+
+// ? Helper function to collect the metrics recorded under the greipotel scope
+func collect(t *testing.T, reader *sdkmetric.ManualReader) []metricdata.Metrics {
+	t.Helper()
+	var data metricdata.ResourceMetrics
+	if err := reader.Collect(context.Background(), &data); err != nil {
+		t.Fatal(err)
+	}
+	for _, scope := range data.ScopeMetrics {
+		if scope.Scope.Name == greipotel.ScopeName {
+			return scope.Metrics
+		}
+	}
+	t.Fatalf("no metrics recorded under %s", greipotel.ScopeName)
+	return nil
+}
+
+// ? Helper function to get the data points of a counter, nil if nothing was recorded
+func sumPoints(t *testing.T, metrics []metricdata.Metrics, name string) []metricdata.DataPoint[int64] {
+	t.Helper()
+	for _, m := range metrics {
+		if m.Name == name {
+			sum, ok := m.Data.(metricdata.Sum[int64])
+			if !ok {
+				t.Fatalf("%s is a %T, want a counter", name, m.Data)
+			}
+			return sum.DataPoints
+		}
+	}
+	return nil
+}
+
+// ? Helper function to get the data points of a histogram, nil if nothing was recorded
+func histogramPoints(t *testing.T, metrics []metricdata.Metrics, name string) []metricdata.HistogramDataPoint[float64] {
+	t.Helper()
+	for _, m := range metrics {
+		if m.Name == name {
+			histogram, ok := m.Data.(metricdata.Histogram[float64])
+			if !ok {
+				t.Fatalf("%s is a %T, want a histogram", name, m.Data)
+			}
+			return histogram.DataPoints
+		}
+	}
+	return nil
+}
+
+// ? Helper function to check that a set holds the wanted attributes
+func checkAttributes(t *testing.T, set attribute.Set, want map[attribute.Key]attribute.Value) {
+	t.Helper()
+	for key, value := range want {
+		if got, ok := set.Value(key); !ok || got != value {
+			t.Errorf("attribute %s: got %v, want %v", key, got.Emit(), value.Emit())
+		}
+	}
+	if _, ok := set.Value("error.type"); ok && want["error.type"].Type() == attribute.INVALID {
+		t.Errorf("unexpected error.type attribute in %v", set.Encoded(attribute.DefaultEncoder()))
+	}
+}
+
+type failingMeterProvider struct{ noop.MeterProvider }
+
+func (failingMeterProvider) Meter(string, ...metric.MeterOption) metric.Meter {
+	return failingMeter{}
+}
+
+type failingMeter struct{ noop.Meter }
+
+var errInstrument = errors.New("instrument rejected")
+
+func (failingMeter) Int64Counter(string, ...metric.Int64CounterOption) (metric.Int64Counter, error) {
+	return nil, errInstrument
+}
+
+func (failingMeter) Float64Histogram(string, ...metric.Float64HistogramOption) (metric.Float64Histogram, error) {
+	return nil, errInstrument
+}
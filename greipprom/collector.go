@@ -0,0 +1,167 @@
+// Package greipprom exports Prometheus metrics about the usage of the Greip client:
+// the requests sent per endpoint, outcome and mode, their latency, and the calls saved
+// by the cache and by request coalescing.
+//
+// Example usage:
+//
+//	collector := greipprom.NewCollector(greipprom.WithConstLabels(prometheus.Labels{"service": "checkout"}))
+//	registry.MustRegister(collector)
+//
+//	greipInstance := greip.NewClient("YOUR_API_TOKEN", greip.WithObserver(collector))
+package greipprom
+
+import (
+	"context"
+	"errors"
+
+	greip "github.com/greipio/go"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Option configures a Collector.
+type Option func(*config)
+
+type config struct {
+	namespace   string
+	constLabels prometheus.Labels
+	buckets     []float64
+}
+
+// WithNamespace sets the namespace prefixed to the metric names ("greip" by default).
+func WithNamespace(namespace string) Option {
+	return func(c *config) {
+		c.namespace = namespace
+	}
+}
+
+// WithConstLabels adds labels to every metric, e.g. the name of the service using the client.
+func WithConstLabels(labels prometheus.Labels) Option {
+	return func(c *config) {
+		c.constLabels = labels
+	}
+}
+
+// WithBuckets sets the buckets of the latency histogram, in seconds (prometheus.DefBuckets by default).
+func WithBuckets(buckets []float64) Option {
+	return func(c *config) {
+		c.buckets = buckets
+	}
+}
+
+// Collector is a prometheus.Collector and a greip.Observer. Pass it to greip.WithObserver to collect
+// the metrics of a client, and register it against a registry to export them:
+//
+//   - <namespace>_requests_total: requests sent to the API, by endpoint, outcome and mode ("live" or "test").
+//     Every attempt is counted, so retries count as separate requests.
+//   - <namespace>_request_duration_seconds: latency histogram of the requests, by endpoint and mode.
+//   - <namespace>_cache_hits_total: calls served from the cache, by endpoint and mode.
+//   - <namespace>_coalesced_calls_total: calls that shared the request of an identical call in flight,
+//     by endpoint and mode.
+//
+// The outcome is one of "success", "invalid_input", "unauthorized", "rate_limited", "quota_exceeded",
+// "client_error", "server_error", "network_error" and "canceled". Requests with the "network_error"
+// or "canceled" outcome may not have reached the API.
+type Collector struct {
+	requests  *prometheus.CounterVec
+	duration  *prometheus.HistogramVec
+	cacheHits *prometheus.CounterVec
+	coalesced *prometheus.CounterVec
+}
+
+var (
+	_ prometheus.Collector = (*Collector)(nil)
+	_ greip.Observer       = (*Collector)(nil)
+)
+
+// NewCollector creates a Collector.
+func NewCollector(options ...Option) *Collector {
+	cfg := config{namespace: "greip", buckets: prometheus.DefBuckets}
+	for _, option := range options {
+		option(&cfg)
+	}
+
+	return &Collector{
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace:   cfg.namespace,
+			Name:        "requests_total",
+			Help:        "Number of requests sent to the Greip API.",
+			ConstLabels: cfg.constLabels,
+		}, []string{"endpoint", "outcome", "mode"}),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace:   cfg.namespace,
+			Name:        "request_duration_seconds",
+			Help:        "Latency of the requests sent to the Greip API.",
+			ConstLabels: cfg.constLabels,
+			Buckets:     cfg.buckets,
+		}, []string{"endpoint", "mode"}),
+		cacheHits: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace:   cfg.namespace,
+			Name:        "cache_hits_total",
+			Help:        "Number of calls served from the cache.",
+			ConstLabels: cfg.constLabels,
+		}, []string{"endpoint", "mode"}),
+		coalesced: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace:   cfg.namespace,
+			Name:        "coalesced_calls_total",
+			Help:        "Number of calls that shared the request of an identical call in flight.",
+			ConstLabels: cfg.constLabels,
+		}, []string{"endpoint", "mode"}),
+	}
+}
+
+// Describe implements prometheus.Collector.
+func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
+	c.requests.Describe(ch)
+	c.duration.Describe(ch)
+	c.cacheHits.Describe(ch)
+	c.coalesced.Describe(ch)
+}
+
+// Collect implements prometheus.Collector.
+func (c *Collector) Collect(ch chan<- prometheus.Metric) {
+	c.requests.Collect(ch)
+	c.duration.Collect(ch)
+	c.cacheHits.Collect(ch)
+	c.coalesced.Collect(ch)
+}
+
+// ObserveCall implements greip.Observer.
+func (c *Collector) ObserveCall(event greip.CallEvent) {
+	mode := "live"
+	if event.Test {
+		mode = "test"
+	}
+
+	switch {
+	case event.CacheHit:
+		c.cacheHits.WithLabelValues(event.Endpoint, mode).Inc()
+	case event.Coalesced:
+		c.coalesced.WithLabelValues(event.Endpoint, mode).Inc()
+	default:
+		c.requests.WithLabelValues(event.Endpoint, outcome(event), mode).Inc()
+		c.duration.WithLabelValues(event.Endpoint, mode).Observe(event.Latency.Seconds())
+	}
+}
+
+// ? Helper function to classify the outcome of a request
+func outcome(event greip.CallEvent) string {
+	switch err := event.Err; {
+	case err == nil:
+		return "success"
+	case errors.Is(err, greip.ErrUnauthorized):
+		return "unauthorized"
+	case errors.Is(err, greip.ErrQuotaExceeded):
+		return "quota_exceeded"
+	case errors.Is(err, greip.ErrRateLimited):
+		return "rate_limited"
+	case errors.Is(err, greip.ErrInvalidInput):
+		return "invalid_input"
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return "canceled"
+	case event.StatusCode >= 500:
+		return "server_error"
+	case event.StatusCode != 0:
+		return "client_error"
+	}
+	return "network_error"
+}
@@ -0,0 +1,148 @@
+package greipprom_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	greip "github.com/greipio/go"
+	"github.com/greipio/go/greipprom"
+	"github.com/greipio/go/greiptest"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestCollector(t *testing.T) {
+	server := greiptest.NewServer()
+	defer server.Close()
+	server.Fail("threats", greiptest.Failure{StatusCode: http.StatusUnauthorized, Description: "Invalid API key."})
+
+	collector := greipprom.NewCollector(greipprom.WithConstLabels(prometheus.Labels{"service": "checkout"}))
+	registry := prometheus.NewPedanticRegistry()
+	if err := registry.Register(collector); err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+
+	live := server.Client(greip.WithObserver(collector), greip.WithCache(greip.NewLRUCache(10)))
+	test := server.Client(greip.WithObserver(collector), greip.WithTestMode(true))
+	for i := 0; i < 3; i++ {
+		if _, err := live.Lookup("1.1.1.1", nil); err != nil {
+			t.Fatalf("Lookup: %v", err)
+		}
+	}
+	if _, err := live.Threats("1.1.1.1"); !errors.Is(err, greip.ErrUnauthorized) {
+		t.Fatalf("Threats: got %v, want ErrUnauthorized", err)
+	}
+	if _, err := test.Lookup("1.1.1.1", nil); err != nil {
+		t.Fatalf("Lookup in test mode: %v", err)
+	}
+	//? Coalescing depends on timing, so the collector is notified directly
+	collector.ObserveCall(greip.CallEvent{Endpoint: "IPLookup", Coalesced: true})
+
+	want := `
+# HELP greip_requests_total Number of requests sent to the Greip API.
+# TYPE greip_requests_total counter
+greip_requests_total{endpoint="IPLookup",mode="live",outcome="success",service="checkout"} 1
+greip_requests_total{endpoint="IPLookup",mode="test",outcome="success",service="checkout"} 1
+greip_requests_total{endpoint="threats",mode="live",outcome="unauthorized",service="checkout"} 1
+# HELP greip_cache_hits_total Number of calls served from the cache.
+# TYPE greip_cache_hits_total counter
+greip_cache_hits_total{endpoint="IPLookup",mode="live",service="checkout"} 2
+# HELP greip_coalesced_calls_total Number of calls that shared the request of an identical call in flight.
+# TYPE greip_coalesced_calls_total counter
+greip_coalesced_calls_total{endpoint="IPLookup",mode="live",service="checkout"} 1
+`
+	if err := testutil.GatherAndCompare(registry, strings.NewReader(want),
+		"greip_requests_total", "greip_cache_hits_total", "greip_coalesced_calls_total"); err != nil {
+		t.Fatal(err)
+	}
+
+	//? Every request sent is observed once by the latency histogram, the cached and coalesced calls are not
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	counts := map[string]uint64{}
+	for _, family := range families {
+		if family.GetName() != "greip_request_duration_seconds" {
+			continue
+		}
+		for _, metric := range family.GetMetric() {
+			for _, label := range metric.GetLabel() {
+				if label.GetName() == "endpoint" {
+					counts[label.GetValue()] += metric.GetHistogram().GetSampleCount()
+				}
+			}
+		}
+	}
+	if counts["IPLookup"] != 2 || counts["threats"] != 1 {
+		t.Fatalf("got histogram counts %v, want 2 IPLookup and 1 threats observations", counts)
+	}
+	if problems, err := testutil.GatherAndLint(registry); err != nil || len(problems) != 0 {
+		t.Fatalf("got lint problems %v, %v", problems, err)
+	}
+}
+
+func TestCollectorOutcomes(t *testing.T) {
+	tests := []struct {
+		event   greip.CallEvent
+		outcome string
+	}{
+		{greip.CallEvent{StatusCode: http.StatusOK}, "success"},
+		{greip.CallEvent{StatusCode: http.StatusBadRequest, Err: &greip.ValidationError{Field: "ip"}}, "invalid_input"},
+		{greip.CallEvent{StatusCode: http.StatusTooManyRequests, Err: greip.ErrRateLimited}, "rate_limited"},
+		{greip.CallEvent{StatusCode: http.StatusPaymentRequired, Err: greip.ErrQuotaExceeded}, "quota_exceeded"},
+		{greip.CallEvent{StatusCode: http.StatusNotFound, Err: errors.New("not found")}, "client_error"},
+		{greip.CallEvent{StatusCode: http.StatusBadGateway, Err: errors.New("bad gateway")}, "server_error"},
+		{greip.CallEvent{Err: errors.New("connection refused")}, "network_error"},
+		{greip.CallEvent{Err: context.DeadlineExceeded}, "canceled"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.outcome, func(t *testing.T) {
+			collector := greipprom.NewCollector(greipprom.WithNamespace("app"))
+			tt.event.Endpoint = "IPLookup"
+			collector.ObserveCall(tt.event)
+
+			want := `
+# HELP app_requests_total Number of requests sent to the Greip API.
+# TYPE app_requests_total counter
+app_requests_total{endpoint="IPLookup",mode="live",outcome="` + tt.outcome + `"} 1
+`
+			if err := testutil.CollectAndCompare(collector, strings.NewReader(want), "app_requests_total"); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+func TestCollectorRegisteredTwice(t *testing.T) {
+	collector := greipprom.NewCollector()
+	registry := prometheus.NewRegistry()
+	if err := registry.Register(collector); err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+
+	var err error
+	func() {
+		defer func() {
+			if r := recover(); r != nil {
+				t.Fatalf("Register panicked: %v", r)
+			}
+		}()
+		err = registry.Register(collector)
+	}()
+	var registered prometheus.AlreadyRegisteredError
+	if !errors.As(err, &registered) || registered.ExistingCollector != collector {
+		t.Fatalf("got %v, want an AlreadyRegisteredError", err)
+	}
+
+	//? Another collector with the same metrics is rejected too, unless its namespace differs
+	if err := registry.Register(greipprom.NewCollector()); err == nil {
+		t.Fatal("a second collector with the same metrics was registered")
+	}
+	if err := registry.Register(greipprom.NewCollector(greipprom.WithNamespace("other"))); err != nil {
+		t.Fatalf("Register with another namespace: %v", err)
+	}
+}
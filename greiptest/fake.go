@@ -0,0 +1,244 @@
+package greiptest
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	greip "github.com/greipio/go"
+)
+
+// ErrNotStubbed is returned by the methods of FakeClient whose function field is not set.
+var ErrNotStubbed = errors.New("greiptest: method not stubbed")
+
+// Call is a method call recorded by FakeClient.
+type Call struct {
+	// Method is the name of the called method, without the Context suffix (e.g. "Lookup").
+	Method string
+	// Args are the arguments of the call, except the context.
+	Args []interface{}
+}
+
+// FakeClient is a greip.Client whose methods call the matching function fields, and record every call.
+// The methods with and without the Context suffix share the same function field. Methods whose
+// field is nil return ErrNotStubbed, except LookupStream which falls back to LookupFunc.
+//
+// Example usage:
+//
+//	fake := &greiptest.FakeClient{
+//	    LookupFunc: func(ctx context.Context, ip string, params []string, lang ...string) (*greip.ResponseLookup, error) {
+//	        return &greip.ResponseLookup{IP: ip, CountryCode: "FR"}, nil
+//	    },
+//	}
+//	service := NewService(fake)
+type FakeClient struct {
+	LookupFunc       func(ctx context.Context, ip string, params []string, lang ...string) (*greip.ResponseLookup, error)
+	LookupStreamFunc func(ctx context.Context, in <-chan string, params []string, lang ...string) <-chan greip.LookupResult
+	ThreatsFunc      func(ctx context.Context, ip string) (*greip.ResponseThreats, error)
+	BulkLookupFunc   func(ctx context.Context, ips []string, params []string, lang ...string) (*map[string]greip.ResponseLookup, error)
+	CountryFunc      func(ctx context.Context, countryCode string, params []string, lang ...string) (*greip.ResponseCountry, error)
+	ProfanityFunc    func(ctx context.Context, text string) (*greip.ResponseProfanity, error)
+	AsnLookupFunc    func(ctx context.Context, asn string) (*greip.ResponseASN, error)
+	EmailFunc        func(ctx context.Context, email string) (*greip.ResponseEmail, error)
+	PhoneFunc        func(ctx context.Context, phone string, countryCode string) (*greip.ResponsePhone, error)
+	IBANFunc         func(ctx context.Context, iban string) (*greip.ResponseIBAN, error)
+	PaymentFunc      func(ctx context.Context, data map[string]interface{}) (*greip.ResponsePayment, error)
+	PaymentTypedFunc func(ctx context.Context, request greip.PaymentRequest) (*greip.ResponsePayment, error)
+
+	mu    sync.Mutex
+	calls []Call
+}
+
+var _ greip.Client = (*FakeClient)(nil)
+
+// Calls returns the calls made so far, in order.
+func (f *FakeClient) Calls() []Call {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]Call(nil), f.calls...)
+}
+
+// CallsTo returns the calls made so far to method, in order.
+func (f *FakeClient) CallsTo(method string) []Call {
+	var calls []Call
+	for _, call := range f.Calls() {
+		if call.Method == method {
+			calls = append(calls, call)
+		}
+	}
+	return calls
+}
+
+// Reset forgets the recorded calls.
+func (f *FakeClient) Reset() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls = nil
+}
+
+func (f *FakeClient) record(method string, args ...interface{}) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls = append(f.calls, Call{Method: method, Args: args})
+}
+
+func (f *FakeClient) Lookup(ip string, params []string, lang ...string) (*greip.ResponseLookup, error) {
+	return f.LookupContext(context.Background(), ip, params, lang...)
+}
+
+func (f *FakeClient) LookupContext(ctx context.Context, ip string, params []string, lang ...string) (*greip.ResponseLookup, error) {
+	f.record("Lookup", ip, params, lang)
+	if f.LookupFunc == nil {
+		return nil, ErrNotStubbed
+	}
+	return f.LookupFunc(ctx, ip, params, lang...)
+}
+
+func (f *FakeClient) LookupStream(ctx context.Context, in <-chan string, params []string, lang ...string) <-chan greip.LookupResult {
+	f.record("LookupStream", params, lang)
+	if f.LookupStreamFunc != nil {
+		return f.LookupStreamFunc(ctx, in, params, lang...)
+	}
+
+	//? Fall back to looking up the IP addresses one by one
+	out := make(chan greip.LookupResult)
+	go func() {
+		defer close(out)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case ip, ok := <-in:
+				if !ok {
+					return
+				}
+				result := greip.LookupResult{IP: ip, Err: ErrNotStubbed}
+				if f.LookupFunc != nil {
+					result.Response, result.Err = f.LookupFunc(ctx, ip, params, lang...)
+				}
+				select {
+				case out <- result:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+	return out
+}
+
+func (f *FakeClient) Threats(ip string) (*greip.ResponseThreats, error) {
+	return f.ThreatsContext(context.Background(), ip)
+}
+
+func (f *FakeClient) ThreatsContext(ctx context.Context, ip string) (*greip.ResponseThreats, error) {
+	f.record("Threats", ip)
+	if f.ThreatsFunc == nil {
+		return nil, ErrNotStubbed
+	}
+	return f.ThreatsFunc(ctx, ip)
+}
+
+func (f *FakeClient) BulkLookup(ips []string, params []string, lang ...string) (*map[string]greip.ResponseLookup, error) {
+	return f.BulkLookupContext(context.Background(), ips, params, lang...)
+}
+
+func (f *FakeClient) BulkLookupContext(ctx context.Context, ips []string, params []string, lang ...string) (*map[string]greip.ResponseLookup, error) {
+	f.record("BulkLookup", ips, params, lang)
+	if f.BulkLookupFunc == nil {
+		return nil, ErrNotStubbed
+	}
+	return f.BulkLookupFunc(ctx, ips, params, lang...)
+}
+
+func (f *FakeClient) Country(countryCode string, params []string, lang ...string) (*greip.ResponseCountry, error) {
+	return f.CountryContext(context.Background(), countryCode, params, lang...)
+}
+
+func (f *FakeClient) CountryContext(ctx context.Context, countryCode string, params []string, lang ...string) (*greip.ResponseCountry, error) {
+	f.record("Country", countryCode, params, lang)
+	if f.CountryFunc == nil {
+		return nil, ErrNotStubbed
+	}
+	return f.CountryFunc(ctx, countryCode, params, lang...)
+}
+
+func (f *FakeClient) Profanity(text string) (*greip.ResponseProfanity, error) {
+	return f.ProfanityContext(context.Background(), text)
+}
+
+func (f *FakeClient) ProfanityContext(ctx context.Context, text string) (*greip.ResponseProfanity, error) {
+	f.record("Profanity", text)
+	if f.ProfanityFunc == nil {
+		return nil, ErrNotStubbed
+	}
+	return f.ProfanityFunc(ctx, text)
+}
+
+func (f *FakeClient) AsnLookup(asn string) (*greip.ResponseASN, error) {
+	return f.AsnLookupContext(context.Background(), asn)
+}
+
+func (f *FakeClient) AsnLookupContext(ctx context.Context, asn string) (*greip.ResponseASN, error) {
+	f.record("AsnLookup", asn)
+	if f.AsnLookupFunc == nil {
+		return nil, ErrNotStubbed
+	}
+	return f.AsnLookupFunc(ctx, asn)
+}
+
+func (f *FakeClient) Email(email string) (*greip.ResponseEmail, error) {
+	return f.EmailContext(context.Background(), email)
+}
+
+func (f *FakeClient) EmailContext(ctx context.Context, email string) (*greip.ResponseEmail, error) {
+	f.record("Email", email)
+	if f.EmailFunc == nil {
+		return nil, ErrNotStubbed
+	}
+	return f.EmailFunc(ctx, email)
+}
+
+func (f *FakeClient) Phone(phone string, countryCode string) (*greip.ResponsePhone, error) {
+	return f.PhoneContext(context.Background(), phone, countryCode)
+}
+
+func (f *FakeClient) PhoneContext(ctx context.Context, phone string, countryCode string) (*greip.ResponsePhone, error) {
+	f.record("Phone", phone, countryCode)
+	if f.PhoneFunc == nil {
+		return nil, ErrNotStubbed
+	}
+	return f.PhoneFunc(ctx, phone, countryCode)
+}
+
+func (f *FakeClient) IBAN(iban string) (*greip.ResponseIBAN, error) {
+	return f.IBANContext(context.Background(), iban)
+}
+
+func (f *FakeClient) IBANContext(ctx context.Context, iban string) (*greip.ResponseIBAN, error) {
+	f.record("IBAN", iban)
+	if f.IBANFunc == nil {
+		return nil, ErrNotStubbed
+	}
+	return f.IBANFunc(ctx, iban)
+}
+
+func (f *FakeClient) Payment(data map[string]interface{}) (*greip.ResponsePayment, error) {
+	return f.PaymentContext(context.Background(), data)
+}
+
+func (f *FakeClient) PaymentContext(ctx context.Context, data map[string]interface{}) (*greip.ResponsePayment, error) {
+	f.record("Payment", data)
+	if f.PaymentFunc == nil {
+		return nil, ErrNotStubbed
+	}
+	return f.PaymentFunc(ctx, data)
+}
+
+func (f *FakeClient) PaymentTyped(ctx context.Context, request greip.PaymentRequest) (*greip.ResponsePayment, error) {
+	f.record("PaymentTyped", request)
+	if f.PaymentTypedFunc == nil {
+		return nil, ErrNotStubbed
+	}
+	return f.PaymentTypedFunc(ctx, request)
+}
@@ -0,0 +1,144 @@
+package greiptest_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+
+	greip "github.com/greipio/go"
+	"github.com/greipio/go/greiptest"
+)
+
+func TestFakeClientRecordsCalls(t *testing.T) {
+	fake := &greiptest.FakeClient{
+		LookupFunc: func(ctx context.Context, ip string, params []string, lang ...string) (*greip.ResponseLookup, error) {
+			return &greip.ResponseLookup{IP: ip, CountryCode: "FR"}, nil
+		},
+	}
+	var client greip.Client = fake
+
+	response, err := client.Lookup("1.1.1.1", []string{"security"}, "EN")
+	if err != nil || response.IP != "1.1.1.1" || response.CountryCode != "FR" {
+		t.Fatalf("Lookup: %+v, %v, want the stubbed response", response, err)
+	}
+	if _, err := client.LookupContext(context.Background(), "2.2.2.2", nil); err != nil {
+		t.Fatalf("LookupContext: %v", err)
+	}
+	if _, err := client.Phone("0612345678", "FR"); !errors.Is(err, greiptest.ErrNotStubbed) {
+		t.Fatalf("Phone: got %v, want ErrNotStubbed", err)
+	}
+
+	//? Methods with and without the Context suffix are recorded under the same name
+	want := []greiptest.Call{
+		{Method: "Lookup", Args: []interface{}{"1.1.1.1", []string{"security"}, []string{"EN"}}},
+		{Method: "Lookup", Args: []interface{}{"2.2.2.2", []string(nil), []string(nil)}},
+		{Method: "Phone", Args: []interface{}{"0612345678", "FR"}},
+	}
+	if calls := fake.Calls(); !reflect.DeepEqual(calls, want) {
+		t.Fatalf("got calls %+v, want %+v", calls, want)
+	}
+	if calls := fake.CallsTo("Lookup"); len(calls) != 2 {
+		t.Fatalf("got %d Lookup calls, want 2", len(calls))
+	}
+
+	fake.Reset()
+	if calls := fake.Calls(); len(calls) != 0 {
+		t.Fatalf("got calls %+v after Reset, want none", calls)
+	}
+}
+
+func TestFakeClientNotStubbed(t *testing.T) {
+	ctx := context.Background()
+	fake := &greiptest.FakeClient{}
+	tests := []struct {
+		method string
+		call   func() error
+	}{
+		{"Lookup", func() error { _, err := fake.Lookup("1.1.1.1", nil); return err }},
+		{"Threats", func() error { _, err := fake.Threats("1.1.1.1"); return err }},
+		{"BulkLookup", func() error { _, err := fake.BulkLookup([]string{"1.1.1.1"}, nil); return err }},
+		{"Country", func() error { _, err := fake.Country("FR", nil); return err }},
+		{"Profanity", func() error { _, err := fake.Profanity("text"); return err }},
+		{"AsnLookup", func() error { _, err := fake.AsnLookup("AS13335"); return err }},
+		{"Email", func() error { _, err := fake.Email("name@domain.com"); return err }},
+		{"Phone", func() error { _, err := fake.Phone("0612345678", "FR"); return err }},
+		{"IBAN", func() error { _, err := fake.IBAN("DE89370400440532013000"); return err }},
+		{"Payment", func() error { _, err := fake.Payment(map[string]interface{}{"customer_id": "1"}); return err }},
+		{"PaymentTyped", func() error { _, err := fake.PaymentTyped(ctx, greip.PaymentRequest{CustomerID: "1"}); return err }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.method, func(t *testing.T) {
+			fake.Reset()
+			if err := tt.call(); !errors.Is(err, greiptest.ErrNotStubbed) {
+				t.Fatalf("got %v, want ErrNotStubbed", err)
+			}
+			if calls := fake.CallsTo(tt.method); len(calls) != 1 {
+				t.Fatalf("got calls %+v, want one %s call", fake.Calls(), tt.method)
+			}
+		})
+	}
+}
+
+func TestFakeClientLookupStream(t *testing.T) {
+	stream := func(client greip.Client, ips ...string) []greip.LookupResult {
+		in := make(chan string, len(ips))
+		for _, ip := range ips {
+			in <- ip
+		}
+		close(in)
+		var results []greip.LookupResult
+		for result := range client.LookupStream(context.Background(), in, nil) {
+			results = append(results, result)
+		}
+		return results
+	}
+
+	//? Without any stub, every IP address fails
+	results := stream(&greiptest.FakeClient{}, "1.1.1.1", "2.2.2.2")
+	if len(results) != 2 || !errors.Is(results[0].Err, greiptest.ErrNotStubbed) || !errors.Is(results[1].Err, greiptest.ErrNotStubbed) {
+		t.Fatalf("got %+v, want ErrNotStubbed for every IP address", results)
+	}
+
+	//? LookupFunc answers for each IP address when LookupStreamFunc is not set
+	fake := &greiptest.FakeClient{
+		LookupFunc: func(ctx context.Context, ip string, params []string, lang ...string) (*greip.ResponseLookup, error) {
+			return &greip.ResponseLookup{IP: ip}, nil
+		},
+	}
+	results = stream(fake, "1.1.1.1", "2.2.2.2")
+	if len(results) != 2 || results[0].Response.IP != "1.1.1.1" || results[1].Response.IP != "2.2.2.2" {
+		t.Fatalf("got %+v, want the results of LookupFunc", results)
+	}
+	if len(fake.CallsTo("LookupStream")) != 1 {
+		t.Fatalf("got calls %+v, want one LookupStream call", fake.Calls())
+	}
+
+	//? LookupStreamFunc takes precedence
+	fake.LookupStreamFunc = func(ctx context.Context, in <-chan string, params []string, lang ...string) <-chan greip.LookupResult {
+		out := make(chan greip.LookupResult, 1)
+		out <- greip.LookupResult{IP: "stubbed"}
+		close(out)
+		return out
+	}
+	if results = stream(fake, "1.1.1.1"); len(results) != 1 || results[0].IP != "stubbed" {
+		t.Fatalf("got %+v, want the result of LookupStreamFunc", results)
+	}
+}
+
+func TestFakeClientConcurrentCalls(t *testing.T) {
+	fake := &greiptest.FakeClient{}
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			fake.Threats("1.1.1.1")
+		}()
+	}
+	wg.Wait()
+	if calls := fake.CallsTo("Threats"); len(calls) != 20 {
+		t.Fatalf("got %d calls, want 20", len(calls))
+	}
+}
@@ -0,0 +1,260 @@
+package greiptest
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// RecorderMode controls whether a Recorder sends requests to the network or replays a cassette.
+type RecorderMode int
+
+const (
+	// ModeReplayOrRecord replays the cassette if the file exists, and records a new one otherwise.
+	ModeReplayOrRecord RecorderMode = iota
+	// ModeReplay only replays the cassette, failing the requests it doesn't contain.
+	ModeReplay
+	// ModeRecord always sends the requests to the network and overwrites the cassette.
+	ModeRecord
+)
+
+// ? Value written in place of the bearer token in cassettes
+const redactedToken = "Bearer [REDACTED]"
+
+// Interaction is a request and its response, as stored in a cassette file.
+type Interaction struct {
+	Request  RecordedRequest  `json:"request"`
+	Response RecordedResponse `json:"response"`
+}
+
+// RecordedRequest is a recorded request. The URL has its host stripped and its query parameters
+// sorted, and the JSON body is normalized, so that requests match regardless of the parameter order.
+type RecordedRequest struct {
+	Method string      `json:"method"`
+	URL    string      `json:"url"`
+	Header http.Header `json:"header,omitempty"`
+	Body   string      `json:"body,omitempty"`
+}
+
+// RecordedResponse is a recorded response.
+type RecordedResponse struct {
+	StatusCode int         `json:"status_code"`
+	Header     http.Header `json:"header,omitempty"`
+	Body       string      `json:"body"`
+}
+
+// Recorder is an http.RoundTripper capturing the requests sent to the Greip API and their responses
+// to a cassette file, so that they can be replayed offline afterwards. The bearer token is never
+// written to the cassette.
+//
+// Example usage:
+//
+//	recorder, err := greiptest.NewRecorder("testdata/lookup.json", greiptest.ModeReplayOrRecord, nil)
+//	if err != nil {
+//	    t.Fatal(err)
+//	}
+//	client := greip.NewClient(os.Getenv("GREIP_TOKEN"), greip.WithTransport(recorder))
+type Recorder struct {
+	path      string
+	mode      RecorderMode
+	transport http.RoundTripper
+
+	mu           sync.Mutex
+	recording    bool
+	interactions []Interaction
+	replayed     map[int]bool
+}
+
+// NewRecorder creates a Recorder using the cassette at path. Requests are sent to the network
+// through transport, or http.DefaultTransport if nil.
+func NewRecorder(path string, mode RecorderMode, transport http.RoundTripper) (*Recorder, error) {
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	r := &Recorder{path: path, mode: mode, transport: transport, replayed: make(map[int]bool)}
+
+	data, err := os.ReadFile(path)
+	switch {
+	case mode == ModeRecord:
+		r.recording = true
+	case err == nil:
+		if err := json.Unmarshal(data, &r.interactions); err != nil {
+			return nil, fmt.Errorf("greiptest: invalid cassette %s: %w", path, err)
+		}
+	case errors.Is(err, os.ErrNotExist) && mode == ModeReplayOrRecord:
+		r.recording = true
+	default:
+		return nil, fmt.Errorf("greiptest: cannot read cassette: %w", err)
+	}
+	return r, nil
+}
+
+// Recording reports whether the recorder sends the requests to the network.
+func (r *Recorder) Recording() bool {
+	return r.recording
+}
+
+// Interactions returns the interactions of the cassette.
+func (r *Recorder) Interactions() []Interaction {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]Interaction(nil), r.interactions...)
+}
+
+// RoundTrip replays the response recorded for req, or sends req to the network and records its response.
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	recorded, err := recordRequest(req)
+	if err != nil {
+		closeBody(req)
+		return nil, err
+	}
+
+	if !r.recording {
+		closeBody(req)
+		interaction, ok := r.match(recorded)
+		if !ok {
+			return nil, fmt.Errorf("greiptest: no recorded interaction for %s %s", recorded.Method, recorded.URL)
+		}
+		return interaction.Response.toResponse(req), nil
+	}
+
+	resp, err := r.transport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.interactions = append(r.interactions, Interaction{
+		Request: recorded,
+		Response: RecordedResponse{
+			StatusCode: resp.StatusCode,
+			Header:     resp.Header.Clone(),
+			Body:       string(body),
+		},
+	})
+	if err := r.save(); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// ? Helper function to find the first interaction matching the request that was not replayed yet,
+// falling back to the last matching one so that a request can be replayed any number of times
+func (r *Recorder) match(recorded RecordedRequest) (Interaction, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	last := -1
+	for i, interaction := range r.interactions {
+		if interaction.Request.Method != recorded.Method || interaction.Request.URL != recorded.URL || interaction.Request.Body != recorded.Body {
+			continue
+		}
+		if !r.replayed[i] {
+			r.replayed[i] = true
+			return interaction, true
+		}
+		last = i
+	}
+	if last < 0 {
+		return Interaction{}, false
+	}
+	return r.interactions[last], true
+}
+
+// ? Helper function to write the cassette, creating its directory if needed
+func (r *Recorder) save() error {
+	var data bytes.Buffer
+	encoder := json.NewEncoder(&data)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(r.interactions); err != nil {
+		return err
+	}
+	if dir := filepath.Dir(r.path); dir != "" {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return err
+		}
+	}
+	return os.WriteFile(r.path, data.Bytes(), 0o644)
+}
+
+// ? Helper function to turn a request into its normalized, redacted form
+func recordRequest(req *http.Request) (RecordedRequest, error) {
+	recorded := RecordedRequest{
+		Method: req.Method,
+		URL:    req.URL.Path,
+		Header: req.Header.Clone(),
+	}
+	if query := req.URL.Query(); len(query) > 0 {
+		recorded.URL += "?" + query.Encode()
+	}
+	if recorded.Header.Get("Authorization") != "" {
+		recorded.Header.Set("Authorization", redactedToken)
+	}
+
+	//? The body is read from a copy: a RoundTripper must not modify the request it is given
+	if req.Body != nil && req.Body != http.NoBody {
+		if req.GetBody == nil {
+			return RecordedRequest{}, errors.New("greiptest: cannot record a request body without GetBody")
+		}
+		copied, err := req.GetBody()
+		if err != nil {
+			return RecordedRequest{}, err
+		}
+		defer copied.Close()
+		body, err := io.ReadAll(copied)
+		if err != nil {
+			return RecordedRequest{}, err
+		}
+		recorded.Body = normalizeJSON(body)
+	}
+	return recorded, nil
+}
+
+// ? Helper function to close the body of a request that is not sent, as a RoundTripper must
+func closeBody(req *http.Request) {
+	if req.Body != nil {
+		req.Body.Close()
+	}
+}
+
+// ? Helper function to re-encode a JSON document with its object keys sorted
+func normalizeJSON(body []byte) string {
+	var value interface{}
+	if err := json.Unmarshal(body, &value); err != nil {
+		return strings.TrimSpace(string(body))
+	}
+	normalized, err := json.Marshal(value)
+	if err != nil {
+		return string(body)
+	}
+	return string(normalized)
+}
+
+func (r RecordedResponse) toResponse(req *http.Request) *http.Response {
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", r.StatusCode, http.StatusText(r.StatusCode)),
+		StatusCode:    r.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        r.Header.Clone(),
+		Body:          io.NopCloser(strings.NewReader(r.Body)),
+		ContentLength: int64(len(r.Body)),
+		Request:       req,
+	}
+}
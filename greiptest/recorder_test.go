@@ -0,0 +1,70 @@
+package greiptest_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	greip "github.com/greipio/go"
+	"github.com/greipio/go/greiptest"
+)
+
+func TestRecorderRecordAndReplay(t *testing.T) {
+	const token = "secret-token"
+	cassette := filepath.Join(t.TempDir(), "testdata", "cassette.json")
+
+	server := greiptest.NewServer()
+	recorder, err := greiptest.NewRecorder(cassette, greiptest.ModeReplayOrRecord, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !recorder.Recording() {
+		t.Fatal("recorder replays a cassette that does not exist")
+	}
+	client := greip.NewClient(token, greip.WithBaseURL(server.URL), greip.WithTransport(recorder))
+
+	lookup, err := client.Lookup("1.1.1.1", []string{"security"})
+	if err != nil || lookup.IP != "1.1.1.1" {
+		t.Fatalf("Lookup: %+v, %v", lookup, err)
+	}
+	if _, err := client.Payment(map[string]interface{}{"customer_id": "42", "customer_email": "a@example.com"}); err != nil {
+		t.Fatalf("Payment: %v", err)
+	}
+
+	//? The body reached the API intact even though the recorder read it
+	payments := server.RequestsTo("paymentFraud")
+	if len(payments) != 1 {
+		t.Fatalf("got %d payment requests, want 1", len(payments))
+	}
+	if payload, _ := payments[0].Body["data"].(map[string]interface{}); payload["customer_id"] != "42" {
+		t.Fatalf("got body %+v, want the payment body to be sent", payments[0].Body)
+	}
+	server.Close()
+
+	data, err := os.ReadFile(cassette)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), token) || !strings.Contains(string(data), "[REDACTED]") {
+		t.Fatalf("token was not redacted from the cassette:\n%s", data)
+	}
+
+	//? The server is gone: the cassette alone must answer the same requests
+	recorder, err = greiptest.NewRecorder(cassette, greiptest.ModeReplay, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client = greip.NewClient(token, greip.WithBaseURL(server.URL), greip.WithTransport(recorder))
+
+	replayed, err := client.Lookup("1.1.1.1", []string{"security"})
+	if err != nil || replayed.IP != lookup.IP {
+		t.Fatalf("replayed Lookup: %+v, %v", replayed, err)
+	}
+	if _, err := client.Payment(map[string]interface{}{"customer_email": "a@example.com", "customer_id": "42"}); err != nil {
+		t.Fatalf("replayed Payment: %v", err)
+	}
+	if _, err := client.Lookup("8.8.8.8", nil); err == nil {
+		t.Fatal("Lookup of an unrecorded IP address succeeded offline")
+	}
+}
@@ -0,0 +1,395 @@
+// Package greiptest provides utilities for testing code that uses the Greip client:
+// an in-process fake of the Greip API (Server), a record/replay transport (Recorder)
+// and a stub implementation of greip.Client (FakeClient).
+package greiptest
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	greip "github.com/greipio/go"
+)
+
+// Token is the API token used by the clients returned by Server.Client.
+const Token = "greiptest-token"
+
+// Failure describes an error returned by the fake API instead of the fixture.
+type Failure struct {
+	// StatusCode is the HTTP status code of the response. Zero means 200, in which case
+	// the error is only reported in the body, as the Greip API does for invalid input.
+	StatusCode int
+	// Code and Description are sent in the error body.
+	Code        string
+	Description string
+	// Header is added to the response, e.g. a Retry-After header.
+	Header http.Header
+	// Times is the number of requests the failure applies to. Zero means every request.
+	Times int
+}
+
+// Request is a request received by the fake API.
+type Request struct {
+	// Endpoint is the name of the called endpoint (e.g. "IPLookup", "paymentFraud").
+	Endpoint string
+	Method   string
+	// Query holds the query parameters of GET requests.
+	Query url.Values
+	// Body holds the decoded JSON body of POST requests.
+	Body   map[string]interface{}
+	Header http.Header
+	Time   time.Time
+}
+
+// Server is a fake of the Greip API, running on a local httptest.Server.
+//
+// Every endpoint returns a fixture programmed for the requested key (the IP address, email, IBAN, ...),
+// falling back to the default fixture of the endpoint, then to a minimal response echoing the key.
+type Server struct {
+	// URL is the base URL of the fake API, to be passed to greip.WithBaseURL.
+	URL string
+
+	server *httptest.Server
+
+	mu       sync.Mutex
+	fixtures map[string]map[string]interface{}
+	defaults map[string]interface{}
+	failures map[string][]*failure
+	latency  map[string]time.Duration
+	token    string
+	requests []Request
+}
+
+type failure struct {
+	Failure
+	key  string
+	used int
+}
+
+// ? keyParams maps every endpoint to the parameter identifying its fixtures
+var keyParams = map[string]string{
+	"IPLookup":      "ip",
+	"threats":       "ip",
+	"BulkLookup":    "ips",
+	"Country":       "CountryCode",
+	"badWords":      "text",
+	"ASNLookup":     "asn",
+	"validateEmail": "email",
+	"validatePhone": "phone",
+	"validateIBAN":  "iban",
+	"paymentFraud":  "customer_id",
+}
+
+// NewServer starts a fake Greip API. It must be closed with Close once done.
+func NewServer() *Server {
+	s := &Server{
+		fixtures: make(map[string]map[string]interface{}),
+		defaults: make(map[string]interface{}),
+		failures: make(map[string][]*failure),
+		latency:  make(map[string]time.Duration),
+	}
+	s.server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	s.URL = s.server.URL + "/"
+	return s
+}
+
+// Close shuts the server down.
+func (s *Server) Close() {
+	s.server.Close()
+}
+
+// Client returns a Greip client sending its requests to the fake API. Additional options
+// are passed to greip.NewClient.
+func (s *Server) Client(options ...greip.Option) *greip.Greip {
+	options = append([]greip.Option{greip.WithBaseURL(s.URL), greip.WithHTTPClient(s.server.Client())}, options...)
+	return greip.NewClient(Token, options...)
+}
+
+// RequireToken makes the fake API reject the requests that don't carry token with a 401 response.
+func (s *Server) RequireToken(token string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.token = token
+}
+
+// SetFixture sets the data returned by endpoint for key, the value of the parameter identifying
+// the request: the IP address for "IPLookup" and "threats", the country code for "Country",
+// the text for "badWords", the ASN for "ASNLookup", the email, phone or IBAN for the validation
+// endpoints and the customer_id for "paymentFraud". The data is encoded to JSON as the `data`
+// field of the response. BulkLookup uses the fixtures of IPLookup.
+func (s *Server) SetFixture(endpoint, key string, data interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.fixtures[endpoint] == nil {
+		s.fixtures[endpoint] = make(map[string]interface{})
+	}
+	s.fixtures[endpoint][key] = data
+}
+
+// SetDefault sets the data returned by endpoint when no fixture matches the request.
+func (s *Server) SetDefault(endpoint string, data interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.defaults[endpoint] = data
+}
+
+// SetLookup sets the response of Lookup and BulkLookup for ip.
+func (s *Server) SetLookup(ip string, response greip.ResponseLookup) {
+	s.SetFixture("IPLookup", ip, response)
+}
+
+// SetThreats sets the response of Threats for ip.
+func (s *Server) SetThreats(ip string, response greip.ResponseThreats) {
+	s.SetFixture("threats", ip, response)
+}
+
+// SetCountry sets the response of Country for countryCode.
+func (s *Server) SetCountry(countryCode string, response greip.ResponseCountry) {
+	s.SetFixture("Country", countryCode, response)
+}
+
+// SetProfanity sets the response of Profanity for text.
+func (s *Server) SetProfanity(text string, response greip.ResponseProfanity) {
+	s.SetFixture("badWords", text, response)
+}
+
+// SetASN sets the response of AsnLookup for asn.
+func (s *Server) SetASN(asn string, response greip.ResponseASN) {
+	s.SetFixture("ASNLookup", asn, response)
+}
+
+// SetEmail sets the response of Email for email.
+func (s *Server) SetEmail(email string, response greip.ResponseEmail) {
+	s.SetFixture("validateEmail", email, response)
+}
+
+// SetPhone sets the response of Phone for phone.
+func (s *Server) SetPhone(phone string, response greip.ResponsePhone) {
+	s.SetFixture("validatePhone", phone, response)
+}
+
+// SetIBAN sets the response of IBAN for iban.
+func (s *Server) SetIBAN(iban string, response greip.ResponseIBAN) {
+	s.SetFixture("validateIBAN", iban, response)
+}
+
+// SetPayment sets the response of Payment for the payments of customerID.
+// An empty customerID sets the response of every payment.
+func (s *Server) SetPayment(customerID string, response greip.ResponsePayment) {
+	if customerID == "" {
+		s.SetDefault("paymentFraud", response)
+		return
+	}
+	s.SetFixture("paymentFraud", customerID, response)
+}
+
+// Fail makes endpoint return f instead of its fixtures.
+func (s *Server) Fail(endpoint string, f Failure) {
+	s.FailKey(endpoint, "", f)
+}
+
+// FailKey is like Fail but only applies to the requests for key (see SetFixture).
+func (s *Server) FailKey(endpoint, key string, f Failure) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failures[endpoint] = append(s.failures[endpoint], &failure{Failure: f, key: key})
+}
+
+// SetLatency delays the responses of endpoint by d. An empty endpoint applies to every endpoint.
+func (s *Server) SetLatency(endpoint string, d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.latency[endpoint] = d
+}
+
+// Requests returns the requests received so far, in order.
+func (s *Server) Requests() []Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Request(nil), s.requests...)
+}
+
+// RequestsTo returns the requests received so far by endpoint, in order.
+func (s *Server) RequestsTo(endpoint string) []Request {
+	var requests []Request
+	for _, r := range s.Requests() {
+		if r.Endpoint == endpoint {
+			requests = append(requests, r)
+		}
+	}
+	return requests
+}
+
+// Reset removes the fixtures, failures, latencies and recorded requests.
+func (s *Server) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.fixtures = make(map[string]map[string]interface{})
+	s.defaults = make(map[string]interface{})
+	s.failures = make(map[string][]*failure)
+	s.latency = make(map[string]time.Duration)
+	s.requests = nil
+}
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	endpoint := strings.Trim(r.URL.Path, "/")
+	request := Request{
+		Endpoint: endpoint,
+		Method:   r.Method,
+		Query:    r.URL.Query(),
+		Header:   r.Header.Clone(),
+		Time:     time.Now(),
+	}
+	if r.Method == http.MethodPost {
+		body, _ := io.ReadAll(r.Body)
+		_ = json.Unmarshal(body, &request.Body)
+	}
+
+	s.mu.Lock()
+	s.requests = append(s.requests, request)
+	token := s.token
+	latency, ok := s.latency[endpoint]
+	if !ok {
+		latency = s.latency[""]
+	}
+	s.mu.Unlock()
+
+	//? Simulate the latency, unless the client gives up first
+	if latency > 0 {
+		select {
+		case <-time.After(latency):
+		case <-r.Context().Done():
+			return
+		}
+	}
+
+	if token != "" && r.Header.Get("Authorization") != "Bearer "+token {
+		writeFailure(w, Failure{StatusCode: http.StatusUnauthorized, Code: "101", Description: "Invalid API key."})
+		return
+	}
+
+	keyParam, known := keyParams[endpoint]
+	if !known {
+		writeFailure(w, Failure{StatusCode: http.StatusNotFound, Description: "Unknown endpoint: " + endpoint})
+		return
+	}
+	key := request.key(keyParam)
+
+	if f, ok := s.takeFailure(endpoint, key); ok {
+		writeFailure(w, f)
+		return
+	}
+
+	writeData(w, s.data(endpoint, key))
+}
+
+// ? Helper function to get the value of the parameter identifying the request
+func (r Request) key(param string) string {
+	if r.Method == http.MethodPost {
+		if data, ok := r.Body["data"].(map[string]interface{}); ok {
+			if value, ok := data[param].(string); ok {
+				return value
+			}
+		}
+		return ""
+	}
+	return r.Query.Get(param)
+}
+
+// ? Helper function to find a failure applying to the request, consuming one of its uses
+func (s *Server) takeFailure(endpoint, key string) (Failure, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	failures := s.failures[endpoint]
+	for i, f := range failures {
+		if f.key != "" && f.key != key {
+			continue
+		}
+		f.used++
+		if f.Times > 0 && f.used >= f.Times {
+			s.failures[endpoint] = append(failures[:i:i], failures[i+1:]...)
+		}
+		return f.Failure, true
+	}
+	return Failure{}, false
+}
+
+// ? Helper function to find the data returned for a request
+func (s *Server) data(endpoint, key string) interface{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if endpoint == "BulkLookup" {
+		results := make(map[string]interface{})
+		for _, ip := range strings.Split(key, ",") {
+			if ip == "" {
+				continue
+			}
+			results[ip] = s.lookupData("IPLookup", ip)
+		}
+		return results
+	}
+	return s.lookupData(endpoint, key)
+}
+
+// ? Helper function to get the fixture of a key, falling back to the default fixture, then to a minimal response
+func (s *Server) lookupData(endpoint, key string) interface{} {
+	if data, ok := s.fixtures[endpoint][key]; ok {
+		return data
+	}
+	if data, ok := s.defaults[endpoint]; ok {
+		return data
+	}
+
+	switch endpoint {
+	case "IPLookup":
+		return greip.ResponseLookup{IP: key}
+	case "threats":
+		return greip.ResponseThreats{IP: key}
+	case "Country":
+		return greip.ResponseCountry{CountryCode: key}
+	case "badWords":
+		return greip.ResponseProfanity{Text: key, IsSafe: true}
+	case "ASNLookup":
+		return greip.ResponseASN{ASN: key}
+	case "validateEmail":
+		return greip.ResponseEmail{Email: key, IsValid: true}
+	case "validatePhone":
+		return greip.ResponsePhone{Phone: key, IsValid: true}
+	case "validateIBAN":
+		return greip.ResponseIBAN{IBAN: key, IsValid: true}
+	default:
+		return greip.ResponsePayment{}
+	}
+}
+
+func writeData(w http.ResponseWriter, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{
+		"status": "success",
+		"data":   data,
+	})
+}
+
+func writeFailure(w http.ResponseWriter, f Failure) {
+	for key, values := range f.Header {
+		for _, value := range values {
+			w.Header().Add(key, value)
+		}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if f.StatusCode != 0 {
+		w.WriteHeader(f.StatusCode)
+	}
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{
+		"status":      "error",
+		"code":        f.Code,
+		"description": f.Description,
+	})
+}
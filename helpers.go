@@ -2,156 +2,257 @@ package greip
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 	"strings"
+	"time"
 )
 
-// ? Helper function to perform an HTTP GET request
-func (g *Greip) getRequest(endpoint string, responseType interface{}, payload ...map[string]interface{}) error {
-	baseURL := g.BaseURL
-	urlEndpoint := fmt.Sprintf("%s%s", baseURL, endpoint)
+// ? result is the outcome of a request: the response, its raw body and its decoded envelope
+type result struct {
+	resp      *http.Response
+	body      []byte
+	envelope  map[string]json.RawMessage
+	decodeErr error
+}
 
-	// Prepare headers
-	req, err := http.NewRequest("GET", urlEndpoint, nil)
-	if err != nil {
-		return err
-	}
-	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", g.token))
-	req.Header.Set("Content-Type", "application/json")
+// ? Helper function to call an endpoint and decode the `data` field of the response into T.
+// GET requests send the payload as query parameters, go through the cache and are coalesced,
+// POST requests send it as the JSON body.
+func do[T any](ctx context.Context, g *Greip, method, endpoint string, payload map[string]interface{}) (T, Meta, error) {
+	var response T
+	urlEndpoint := fmt.Sprintf("%s%s", g.BaseURL, endpoint)
 
 	// If test mode is enabled, add the 'mode' to the payload
-	if g.test && len(payload) > 0 {
-		if payload[0] == nil {
-			payload[0] = make(map[string]interface{})
+	if payload == nil {
+		payload = make(map[string]interface{})
+	}
+	if g.test {
+		payload["mode"] = "test"
+	}
+
+	var newRequest func(ctx context.Context) (*http.Request, error)
+	var key string
+	var ttl time.Duration
+	if method == http.MethodGet {
+		// Construct query parameters from the payload
+		query := url.Values{}
+		for key, value := range payload {
+			query.Add(key, fmt.Sprintf("%v", value))
+		}
+		rawQuery := query.Encode()
+		newRequest = func(ctx context.Context) (*http.Request, error) {
+			req, err := http.NewRequestWithContext(ctx, method, urlEndpoint, nil)
+			if err != nil {
+				return nil, err
+			}
+			req.URL.RawQuery = rawQuery
+			g.setHeaders(req)
+			return req, nil
+		}
+		key, ttl = cacheKey(g.test, endpoint, query), g.cacheTTL(endpoint)
+	} else {
+		// Encode the payload
+		payloadBytes, err := json.Marshal(payload)
+		if err != nil {
+			return response, Meta{}, err
+		}
+		newRequest = func(ctx context.Context) (*http.Request, error) {
+			req, err := http.NewRequestWithContext(ctx, method, urlEndpoint, bytes.NewReader(payloadBytes))
+			if err != nil {
+				return nil, err
+			}
+			g.setHeaders(req)
+			return req, nil
 		}
-		payload[0]["mode"] = "test"
 	}
 
-	// Construct query parameters from the payload
-	query := req.URL.Query()
-	for key, value := range payload[0] {
-		query.Add(key, fmt.Sprintf("%v", value))
+	// Serve the response from the cache when possible
+	if ttl > 0 {
+		if data, ok := g.cache.Get(key); ok {
+			if err := json.Unmarshal(data, &response); err == nil {
+				if marker, ok := any(&response).(cacheMarker); ok {
+					marker.markFromCache()
+				}
+				g.observeShared(ctx, endpoint, true, payload)
+				return response, Meta{}, nil
+			}
+		}
 	}
-	req.URL.RawQuery = query.Encode()
 
-	// Execute the request
-	client := &http.Client{}
-	resp, err := client.Do(req)
-	if err != nil {
-		return err
+	// Execute the request, retrying it only if the retry policy allows it for non-idempotent requests
+	call := Call{Endpoint: endpoint, Method: method, Payload: payload}
+	execute := func(ctx context.Context) (*result, error) {
+		return g.send(ctx, call, method == http.MethodGet, newRequest)
 	}
-	defer resp.Body.Close()
 
-	// Check for non-2xx status codes
-	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		return fmt.Errorf("HTTP request failed with status code: %d", resp.StatusCode)
+	// Share GET requests with identical calls in flight, unless coalescing is disabled
+	var res *result
+	var err error
+	if method != http.MethodGet || g.noCoalescing {
+		res, err = execute(ctx)
+	} else {
+		var shared bool
+		res, shared, err = g.flights.do(ctx, key, execute)
+		if shared && err == nil {
+			g.observeShared(ctx, endpoint, false, payload)
+		}
+	}
+	if err != nil {
+		return response, Meta{}, err
+	}
+	if res.decodeErr != nil {
+		return response, Meta{}, res.decodeErr
 	}
 
-	// Decode the JSON response
-	var jsonResponse map[string]interface{}
-	if err := json.NewDecoder(resp.Body).Decode(&jsonResponse); err != nil {
-		return err
+	// Decode the data directly into the response
+	data, ok := res.envelope["data"]
+	if !ok {
+		return response, Meta{}, errors.New("invalid response format: missing data field")
+	}
+	if err := json.Unmarshal(data, &response); err != nil {
+		return response, Meta{}, err
+	}
+	if ttl > 0 {
+		g.cache.Set(key, data, ttl)
 	}
 
-	// Handle API-specific error in the response
-	if status, ok := jsonResponse["status"].(string); ok && strings.ToLower(status) == "error" {
-		description := jsonResponse["description"].(string)
-		return fmt.Errorf("API error: %s", description)
+	meta := parseMeta(res.resp, res.envelope)
+	if setter, ok := any(&response).(metaSetter); ok {
+		setter.setMeta(meta)
 	}
+	return response, meta, nil
+}
 
-	// Extract the data and unmarshal it directly into responseType
-	if data, ok := jsonResponse["data"]; ok {
-		dataBytes, err := json.Marshal(data)
-		if err != nil {
-			return err
+// ? Helper function to send a request built by newRequest, retrying it according to the retry policy.
+// Every attempt goes through the middleware chain.
+func (g *Greip) send(ctx context.Context, call Call, idempotent bool, newRequest func(ctx context.Context) (*http.Request, error)) (*result, error) {
+	attempts := g.retry.attempts(idempotent)
+
+	for attempt := 1; ; attempt++ {
+		call.Attempt = attempt
+		start := time.Now()
+		res, err := g.sendOnce(ctx, call, newRequest)
+		g.observeAttempt(ctx, call, res.resp, err, time.Since(start))
+		if res.resp != nil {
+			g.recordUsage(parseMeta(res.resp, res.envelope))
 		}
-		if err := json.Unmarshal(dataBytes, responseType); err != nil {
-			return err
+
+		if attempt < attempts && g.retry.shouldRetry(ctx, res.resp, err) {
+			// Wait before the next attempt, unless the server or the context does not leave enough time for it
+			if delay, ok := g.retry.backoff(attempt, res.resp); ok {
+				if sleepErr := sleep(ctx, delay); sleepErr == nil {
+					continue
+				} else if ctx.Err() != nil {
+					return nil, ctx.Err()
+				}
+			}
 		}
-	} else {
-		return errors.New("invalid response format: missing data field")
-	}
 
-	return nil
+		return res, err
+	}
 }
 
-// ? Helper function to perform an HTTP POST request
-func (g *Greip) postRequest(endpoint string, responseType interface{}, payload map[string]interface{}) error {
-	baseURL := g.BaseURL
-	urlEndpoint := fmt.Sprintf("%s%s", baseURL, endpoint)
+// ? Helper function to perform a single attempt through the middleware chain,
+// once the rate limits and concurrency caps allow it. The returned result is never nil.
+func (g *Greip) sendOnce(ctx context.Context, call Call, newRequest func(ctx context.Context) (*http.Request, error)) (*result, error) {
+	release, err := g.limits.acquire(ctx, call.Endpoint)
+	if err != nil {
+		return &result{}, err
+	}
+	defer release()
 
-	// Prepare headers
-	req, err := http.NewRequest("POST", urlEndpoint, nil)
+	req, err := newRequest(ctx)
 	if err != nil {
-		return err
+		return &result{}, err
 	}
-	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", g.token))
-	req.Header.Set("Content-Type", "application/json")
+	call.Request = req
 
-	// If test mode is enabled, add the 'mode' to the payload
-	if g.test {
-		payload["mode"] = "test"
+	resp, err := g.chain()(&call)
+	if resp == nil {
+		return &result{}, err
 	}
 
-	// Encode the payload
-	payloadBytes, err := json.Marshal(payload)
-	if err != nil {
-		return err
+	//? A middleware may have replaced the response, in which case its body is still unread
+	if call.result == nil || call.result.resp != resp {
+		body, readErr := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if readErr != nil {
+			return &result{}, readErr
+		}
+		call.result = newResult(resp, body)
 	}
+	return call.result, err
+}
 
-	// Attach the payload to the request
-	req.Body = io.NopCloser(bytes.NewReader(payloadBytes))
+// ? Helper function to perform the HTTP round trip at the end of the middleware chain.
+// The body is read in full, and non-2xx responses as well as API errors reported in the body
+// are turned into an *APIError.
+func (g *Greip) roundTrip(call *Call) (*http.Response, error) {
+	resp, err := g.client().Do(call.Request)
+	if err != nil {
+		return nil, err
+	}
 
-	// Execute the request
-	client := &http.Client{}
-	resp, err := client.Do(req)
+	// Read the whole body so that it can be attached to errors
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
 	if err != nil {
-		return err
+		return nil, err
 	}
-	defer resp.Body.Close()
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	call.result = newResult(resp, body)
 
 	// Check for non-2xx status codes
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		return fmt.Errorf("HTTP request failed with status code: %d", resp.StatusCode)
-	}
-
-	// Decode the JSON response
-	var jsonResponse map[string]interface{}
-	if err := json.NewDecoder(resp.Body).Decode(&jsonResponse); err != nil {
-		return err
+		return resp, newAPIError(resp.StatusCode, body)
 	}
 
 	// Handle API-specific error in the response
-	if status, ok := jsonResponse["status"].(string); ok && strings.ToLower(status) == "error" {
-		description := jsonResponse["description"].(string)
-		return fmt.Errorf("API error: %s", description)
+	if status := rawString(call.result.envelope["status"]); strings.ToLower(status) == "error" {
+		return resp, newAPIError(resp.StatusCode, body)
 	}
+	return resp, nil
+}
 
-	// Extract the data and unmarshal it directly into responseType
-	if data, ok := jsonResponse["data"]; ok {
-		dataBytes, err := json.Marshal(data)
-		if err != nil {
-			return err
-		}
-		if err := json.Unmarshal(dataBytes, responseType); err != nil {
-			return err
-		}
-	} else {
-		return errors.New("invalid response format: missing data field")
+// ? Helper function to decode the envelope of a response once, for both the error check and the data
+func newResult(resp *http.Response, body []byte) *result {
+	res := &result{resp: resp, body: body}
+	res.decodeErr = json.Unmarshal(body, &res.envelope)
+	return res
+}
+
+// ? Helper function to set the headers shared by every request
+func (g *Greip) setHeaders(req *http.Request) {
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", g.token))
+	req.Header.Set("Content-Type", "application/json")
+	if g.userAgent != "" {
+		req.Header.Set("User-Agent", g.userAgent)
 	}
+}
 
-	return nil
+// ? Helper function to get the HTTP client, falling back to a default one for zero-value instances
+func (g *Greip) client() *http.Client {
+	if g.httpClient == nil {
+		return &http.Client{Timeout: defaultTimeout}
+	}
+	return g.httpClient
 }
 
 // ? Helper function to validate params against the available list of parameters
 func validateParams(params []string, availableParams []string) error {
 	for _, param := range params {
 		if !contains(availableParams, param) {
-			return fmt.Errorf("invalid parameter: %s", param)
+			return &ValidationError{
+				Field:   "params",
+				Value:   param,
+				Message: fmt.Sprintf("invalid parameter: %s", param),
+			}
 		}
 	}
 	return nil
@@ -161,7 +262,11 @@ func validateParams(params []string, availableParams []string) error {
 func validateLang(lang string) error {
 	allowedLangs := []string{"EN", "AR", "DE", "FR", "ES", "JA", "ZH", "RU"}
 	if !contains(allowedLangs, strings.ToUpper(lang)) {
-		return fmt.Errorf("invalid language: %s", lang)
+		return &ValidationError{
+			Field:   "lang",
+			Value:   lang,
+			Message: fmt.Sprintf("invalid language: %s", lang),
+		}
 	}
 	return nil
 }
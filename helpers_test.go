@@ -0,0 +1,72 @@
+package greip_test
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"testing"
+
+	greip "github.com/greipio/go"
+)
+
+// ? stubTransport answers every request with the same pre-encoded body, without any network access
+type stubTransport []byte
+
+func (body stubTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Body != nil {
+		io.Copy(io.Discard, req.Body)
+		req.Body.Close()
+	}
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Content-Type": {"application/json"}},
+		Body:       io.NopCloser(bytes.NewReader(body)),
+		Request:    req,
+	}, nil
+}
+
+const lookupBody = `{"status":"success","executionTime":"0.021","creditsUsed":1,"creditsRemaining":9999,"data":{
+	"ip":"1.1.1.1","ipType":"IPv4","IPNumber":16843009,"continentName":"Oceania","continentCode":"OC",
+	"countryName":"Australia","countryCode":"AU","regionName":"Queensland","cityName":"Brisbane",
+	"zipCode":"4000","latitude":"-27.46794","longitude":"153.02809",
+	"location":{"language":{"name":"English","code":"en"},"flag":{"emoji":"🇦🇺","unicode":"U+1F1E6 U+1F1FA"},"phoneCode":"61"},
+	"asn":{"asn":"AS13335","name":"Cloudflare, Inc.","domain":"cloudflare.com","type":"hosting"},
+	"timezone":{"name":"Australia/Brisbane","abbreviation":"AEST","offset":36000,"currentTime":"10:00:00","currentTimestamp":1700000000},
+	"security":{"isProxy":false,"proxyType":null,"isTor":false,"isBot":false,"isRelay":false,"isHosting":true,"threatLevel":"low"}
+}}`
+
+const paymentBody = `{"status":"success","executionTime":"0.034","data":{
+	"score":12,"rules":[{"id":"PF1001","description":"The IP address is located in the billing country."}],"rulesChecked":40,"rulesDetected":1
+}}`
+
+// BenchmarkRequest measures the request pipeline alone: building the request, the round trip through
+// a stub transport answering with a pre-encoded body, and decoding the envelope and the data.
+func BenchmarkRequest(b *testing.B) {
+	newClient := func(body string) *greip.Greip {
+		return greip.NewClient("token",
+			greip.WithBaseURL("https://greip.test/"),
+			greip.WithHTTPClient(&http.Client{Transport: stubTransport(body)}),
+		)
+	}
+
+	b.Run("Lookup", func(b *testing.B) {
+		client := newClient(lookupBody)
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if _, err := client.Lookup("1.1.1.1", []string{"security", "location"}); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	b.Run("Payment", func(b *testing.B) {
+		client := newClient(paymentBody)
+		data := map[string]interface{}{"customer_id": "42", "customer_email": "name@domain.com", "customer_ip": "1.1.1.1"}
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if _, err := client.Payment(data); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
@@ -0,0 +1,133 @@
+package greip
+
+import (
+	"errors"
+	"fmt"
+	"net/netip"
+)
+
+// ErrNonRoutableIP is matched with errors.Is by the errors returned for IP addresses that
+// can't be looked up because they don't belong to the public internet (private ranges,
+// loopback, link-local, documentation ranges, etc.).
+var ErrNonRoutableIP = errors.New("greip: non-routable IP address")
+
+// NonRoutableIPError is returned by Lookup and Threats, and reported per IP address by BulkLookup
+// and LookupStream, when an IP address is non-routable. No request is sent for it.
+type NonRoutableIPError struct {
+	// IP is the normalised IP address.
+	IP string
+	// Range describes the range the IP address belongs to (e.g. "private", "loopback").
+	Range string
+}
+
+func (e *NonRoutableIPError) Error() string {
+	return fmt.Sprintf("non-routable IP address: %s (%s)", e.IP, e.Range)
+}
+
+// Is makes errors.Is(err, ErrNonRoutableIP) report true for non-routable IP errors.
+func (e *NonRoutableIPError) Is(target error) bool {
+	return target == ErrNonRoutableIP
+}
+
+// NonRoutablePolicy tells the client what to do with non-routable IP addresses.
+type NonRoutablePolicy int
+
+const (
+	// NonRoutableReject returns a *NonRoutableIPError without calling the API. This is the default.
+	NonRoutableReject NonRoutablePolicy = iota
+	// NonRoutableSynthesize returns a "private network" response built locally, with only the IP
+	// address and its type filled in and NonRoutable set, without calling the API.
+	NonRoutableSynthesize
+	// NonRoutableSend sends non-routable IP addresses to the API like any other.
+	NonRoutableSend
+)
+
+// WithNonRoutableIPs sets how Lookup, Threats, BulkLookup and LookupStream handle
+// non-routable IP addresses (NonRoutableReject by default).
+func WithNonRoutableIPs(policy NonRoutablePolicy) Option {
+	return func(g *Greip) {
+		g.nonRoutable = policy
+	}
+}
+
+// ? Ranges that are never routed on the public internet, on top of the ones netip already knows about
+var nonRoutablePrefixes = []struct {
+	prefix netip.Prefix
+	name   string
+}{
+	{netip.MustParsePrefix("0.0.0.0/8"), "this network"},
+	{netip.MustParsePrefix("100.64.0.0/10"), "shared address space"},
+	{netip.MustParsePrefix("192.0.0.0/24"), "IETF protocol assignments"},
+	{netip.MustParsePrefix("192.0.2.0/24"), "documentation"},
+	{netip.MustParsePrefix("198.18.0.0/15"), "benchmarking"},
+	{netip.MustParsePrefix("198.51.100.0/24"), "documentation"},
+	{netip.MustParsePrefix("203.0.113.0/24"), "documentation"},
+	{netip.MustParsePrefix("240.0.0.0/4"), "reserved"},
+	{netip.MustParsePrefix("100::/64"), "discard-only"},
+	{netip.MustParsePrefix("2001:db8::/32"), "documentation"},
+}
+
+// ? Helper function to parse an IP address and normalise it: IPv4-mapped IPv6 addresses are unwrapped,
+// zones are stripped and IPv6 addresses are put in their canonical form by netip.Addr.String
+func normalizeIP(field, ip string) (netip.Addr, error) {
+	if ip == "" {
+		return netip.Addr{}, missingParamError(field)
+	}
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return netip.Addr{}, &ValidationError{
+			Field:   field,
+			Value:   ip,
+			Message: fmt.Sprintf("invalid IP address: %s", ip),
+		}
+	}
+	return addr.Unmap().WithZone(""), nil
+}
+
+// ? Helper function to get the name of the non-routable range an IP address belongs to, if any
+func nonRoutableRange(addr netip.Addr) (string, bool) {
+	switch {
+	case addr.IsUnspecified():
+		return "unspecified", true
+	case addr.IsLoopback():
+		return "loopback", true
+	case addr.IsPrivate():
+		return "private", true
+	case addr.IsLinkLocalUnicast(), addr.IsLinkLocalMulticast():
+		return "link-local", true
+	case addr.IsMulticast():
+		return "multicast", true
+	}
+	for _, r := range nonRoutablePrefixes {
+		if r.prefix.Contains(addr) {
+			return r.name, true
+		}
+	}
+	return "", false
+}
+
+// ? Helper function to check an IP address against the non-routable policy of the client.
+// It returns handled=false when the IP address has to be sent to the API, otherwise
+// either a *NonRoutableIPError or nil when a synthetic response has to be returned.
+func (g *Greip) checkRoutable(addr netip.Addr) (handled bool, err error) {
+	if g.nonRoutable == NonRoutableSend {
+		return false, nil
+	}
+	name, ok := nonRoutableRange(addr)
+	if !ok {
+		return false, nil
+	}
+	if g.nonRoutable == NonRoutableSynthesize {
+		return true, nil
+	}
+	return true, &NonRoutableIPError{IP: addr.String(), Range: name}
+}
+
+// ? Helper function to build the "private network" response of a non-routable IP address
+func privateNetworkLookup(addr netip.Addr) ResponseLookup {
+	ipType := "IPv4"
+	if addr.Is6() {
+		ipType = "IPv6"
+	}
+	return ResponseLookup{IP: addr.String(), IPType: ipType, NonRoutable: true}
+}
@@ -0,0 +1,47 @@
+package greip_test
+
+import (
+	"errors"
+	"testing"
+
+	greip "github.com/greipio/go"
+	"github.com/greipio/go/greiptest"
+)
+
+func TestNonRoutableIPs(t *testing.T) {
+	tests := []struct {
+		name     string
+		options  []greip.Option
+		err      error
+		requests int
+	}{
+		{"rejected by default", nil, greip.ErrNonRoutableIP, 0},
+		{"synthesized", []greip.Option{greip.WithNonRoutableIPs(greip.NonRoutableSynthesize)}, nil, 0},
+		{"sent", []greip.Option{greip.WithNonRoutableIPs(greip.NonRoutableSend)}, nil, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := greiptest.NewServer()
+			defer server.Close()
+			client := server.Client(tt.options...)
+
+			response, err := client.Lookup("10.0.0.1", nil)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("got error %v, want %v", err, tt.err)
+			}
+			if got := len(server.RequestsTo("IPLookup")); got != tt.requests {
+				t.Fatalf("got %d requests, want %d", got, tt.requests)
+			}
+			if err != nil {
+				var rangeErr *greip.NonRoutableIPError
+				if !errors.As(err, &rangeErr) || rangeErr.IP != "10.0.0.1" || rangeErr.Range == "" {
+					t.Fatalf("got error %#v, want a *NonRoutableIPError for 10.0.0.1", err)
+				}
+				return
+			}
+			if response.IP != "10.0.0.1" || response.NonRoutable != (tt.requests == 0) {
+				t.Fatalf("got response %+v", response)
+			}
+		})
+	}
+}
@@ -0,0 +1,173 @@
+package greip
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// ? Maximum number of characters of the string values of a payload when logged
+const maxLoggedValueLength = 64
+
+// WithLogger logs every call to the Greip API with logger: the endpoint, the latency, the HTTP status,
+// the attempt number, whether the response was served from the cache, and the payload. The bearer token
+// is never logged, and emails, phone numbers, IBANs and card numbers are masked unless disabled with
+// WithPayloadRedaction.
+func WithLogger(logger *slog.Logger) Option {
+	return func(g *Greip) {
+		g.logger = logger
+	}
+}
+
+// WithLogLevels sets the level of the logs of successful calls (slog.LevelDebug by default),
+// and of failed calls (slog.LevelWarn by default).
+func WithLogLevels(success, failure slog.Level) Option {
+	return func(g *Greip) {
+		g.logLevels = &[2]slog.Level{success, failure}
+	}
+}
+
+// WithPayloadRedaction enables (the default) or disables the masking of emails, phone numbers,
+// IBANs and card numbers in the logged payloads. Long values are truncated either way.
+func WithPayloadRedaction(enabled bool) Option {
+	return func(g *Greip) {
+		g.noRedaction = !enabled
+	}
+}
+
+// ? Helper function to log an attempt to call the API
+func (g *Greip) logAttempt(ctx context.Context, call Call, resp *http.Response, err error, latency time.Duration) {
+	if g.logger == nil {
+		return
+	}
+
+	attrs := []slog.Attr{
+		slog.String("endpoint", call.Endpoint),
+		slog.String("method", call.Method),
+		slog.Int("attempt", call.Attempt),
+		slog.Duration("latency", latency),
+	}
+	if resp != nil {
+		attrs = append(attrs, slog.Int("status", resp.StatusCode))
+	}
+	g.log(ctx, "greip request", err, attrs, call.Payload)
+}
+
+// ? Helper function to log a response served without calling the API, from the cache or from a coalesced call
+func (g *Greip) logShared(ctx context.Context, endpoint string, cacheHit bool, payload map[string]interface{}) {
+	if g.logger == nil {
+		return
+	}
+
+	attrs := []slog.Attr{
+		slog.String("endpoint", endpoint),
+		slog.Bool("cache_hit", cacheHit),
+		slog.Bool("coalesced", !cacheHit),
+	}
+	g.log(ctx, "greip request served without calling the API", nil, attrs, payload)
+}
+
+func (g *Greip) log(ctx context.Context, message string, err error, attrs []slog.Attr, payload map[string]interface{}) {
+	success, failure := slog.LevelDebug, slog.LevelWarn
+	if g.logLevels != nil {
+		success, failure = g.logLevels[0], g.logLevels[1]
+	}
+
+	level := success
+	if err != nil {
+		level = failure
+		attrs = append(attrs, slog.String("error", err.Error()))
+	}
+	if !g.logger.Enabled(ctx, level) {
+		return
+	}
+
+	attrs = append(attrs, slog.Any("payload", redactPayload(payload, !g.noRedaction)))
+	g.logger.LogAttrs(ctx, level, message, attrs...)
+}
+
+// ? Helper function to copy a payload for logging, masking the sensitive values and truncating the long ones.
+// The payload goes through a JSON round trip first, so that values nested in structs, typed maps and slices
+// are redacted under the same names as the ones sent to the API.
+func redactPayload(payload map[string]interface{}, redact bool) interface{} {
+	encoded, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Sprintf("[payload not loggable: %v]", err)
+	}
+	var generic map[string]interface{}
+	decoder := json.NewDecoder(bytes.NewReader(encoded))
+	decoder.UseNumber()
+	if err := decoder.Decode(&generic); err != nil {
+		return fmt.Sprintf("[payload not loggable: %v]", err)
+	}
+	return redactJSON("", generic, redact)
+}
+
+// ? Helper function to redact a decoded JSON value, the elements of an array being redacted under the key of the array
+func redactJSON(key string, value interface{}, redact bool) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for k, element := range v {
+			v[k] = redactJSON(k, element, redact)
+		}
+		return v
+	case []interface{}:
+		for i, element := range v {
+			v[i] = redactJSON(key, element, redact)
+		}
+		return v
+	case string:
+		if redact {
+			v = redactValue(key, v)
+		}
+		return truncate(v, maxLoggedValueLength)
+	}
+	return value
+}
+
+// ? Helper function to mask a sensitive value according to its key
+func redactValue(key, value string) string {
+	//? Match customer_email, customerEmail and email-address alike
+	key = strings.NewReplacer("_", "", "-", "").Replace(strings.ToLower(key))
+	switch {
+	case strings.Contains(key, "email"):
+		//? Keep the first character and the domain: j***@domain.com
+		at := strings.LastIndex(value, "@")
+		if at < 1 {
+			return mask(value, 0, 0)
+		}
+		_, size := utf8.DecodeRuneInString(value)
+		return value[:size] + "***" + value[at:]
+	case strings.Contains(key, "phone"):
+		return mask(value, 0, 2)
+	case key == "iban":
+		return mask(value, 2, 4)
+	case key == "cardnumber":
+		return mask(value, 6, 0)
+	}
+	return value
+}
+
+// ? Helper function to replace the characters of a value with asterisks, except the first and last ones
+func mask(value string, keepFirst, keepLast int) string {
+	runes := []rune(value)
+	if len(runes) <= keepFirst+keepLast {
+		return strings.Repeat("*", len(runes))
+	}
+	return string(runes[:keepFirst]) + strings.Repeat("*", len(runes)-keepFirst-keepLast) + string(runes[len(runes)-keepLast:])
+}
+
+// ? Helper function to cut a value after n characters, without splitting a multi-byte character
+func truncate(value string, n int) string {
+	if utf8.RuneCountInString(value) <= n {
+		return value
+	}
+	runes := []rune(value)
+	return string(runes[:n]) + "..."
+}
@@ -0,0 +1,165 @@
+package greip_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	greip "github.com/greipio/go"
+	"github.com/greipio/go/greiptest"
+)
+
+type customer struct {
+	Email string `json:"email"`
+	Phone string `json:"phone_number"`
+	Name  string `json:"name"`
+}
+
+func TestRedactPayload(t *testing.T) {
+	long := strings.Repeat("é", 70)
+	tests := []struct {
+		name    string
+		payload map[string]interface{}
+		want    string
+	}{
+		{
+			name:    "top level",
+			payload: map[string]interface{}{"email": "john@domain.com", "phone": "+1 555 0100", "iban": "DE89370400440532013000", "card_number": "4111111111111111", "ip": "1.1.1.1"},
+			want:    `{"card_number":"411111**********","email":"j***@domain.com","iban":"DE****************3000","ip":"1.1.1.1","phone":"*********00"}`,
+		},
+		{
+			name:    "nested map",
+			payload: map[string]interface{}{"data": map[string]interface{}{"customer_email": "john@domain.com", "cardNumber": "4111111111111111"}},
+			want:    `{"data":{"cardNumber":"411111**********","customer_email":"j***@domain.com"}}`,
+		},
+		{
+			name:    "typed map",
+			payload: map[string]interface{}{"data": map[string]string{"customer_phone": "0612345678", "iban": "FR7630006000011234567890189"}},
+			want:    `{"data":{"customer_phone":"********78","iban":"FR*********************0189"}}`,
+		},
+		{
+			name:    "struct",
+			payload: map[string]interface{}{"data": customer{Email: "john@domain.com", Phone: "0612345678", Name: "John"}},
+			want:    `{"data":{"email":"j***@domain.com","name":"John","phone_number":"********78"}}`,
+		},
+		{
+			name:    "slice of maps",
+			payload: map[string]interface{}{"customers": []map[string]interface{}{{"email": "a@b.com"}, {"email": "c@d.com"}}},
+			want:    `{"customers":[{"email":"a***@b.com"},{"email":"c***@d.com"}]}`,
+		},
+		{
+			name:    "slice of values",
+			payload: map[string]interface{}{"emails": []string{"a@b.com", "invalid"}},
+			want:    `{"emails":["a***@b.com","*******"]}`,
+		},
+		{
+			name:    "multi-byte characters",
+			payload: map[string]interface{}{"email": "élodie@domain.com", "phone": "٠٦١٢٣٤", "note": long},
+			want:    `{"email":"é***@domain.com","note":"` + strings.Repeat("é", 64) + `...","phone":"****٣٤"}`,
+		},
+		{
+			name:    "numbers",
+			payload: map[string]interface{}{"amount": 49.9, "quantity": 3},
+			want:    `{"amount":49.9,"quantity":3}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := json.Marshal(greip.RedactPayload(tt.payload, true))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.want {
+				t.Fatalf("got %s, want %s", got, tt.want)
+			}
+			if !utf8.Valid(got) {
+				t.Fatalf("got invalid UTF-8 %q", got)
+			}
+		})
+	}
+}
+
+func TestRedactPayloadDisabled(t *testing.T) {
+	payload := map[string]interface{}{"data": map[string]string{"email": "john@domain.com", "note": strings.Repeat("a", 70)}}
+	want := map[string]interface{}{"data": map[string]interface{}{"email": "john@domain.com", "note": strings.Repeat("a", 64) + "..."}}
+	if got := greip.RedactPayload(payload, false); !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	//? The payload sent to the API is left untouched
+	if payload["data"].(map[string]string)["note"] != strings.Repeat("a", 70) {
+		t.Fatal("the payload was modified")
+	}
+}
+
+func TestLogger(t *testing.T) {
+	var logs bytes.Buffer
+	server := greiptest.NewServer()
+	defer server.Close()
+	client := server.Client(greip.WithLogger(slog.New(slog.NewJSONHandler(&logs, &slog.HandlerOptions{Level: slog.LevelDebug}))))
+
+	_, err := client.Payment(map[string]interface{}{
+		"customer_email": "john@domain.com",
+		"customer":       customer{Email: "jane@domain.com", Phone: "0612345678"},
+		"cart_items":     []map[string]string{{"iban": "DE89370400440532013000"}},
+	})
+	if err != nil {
+		t.Fatalf("Payment: %v", err)
+	}
+
+	output := logs.String()
+	for _, secret := range []string{greiptest.Token, "john@domain.com", "jane@domain.com", "0612345678", "DE89370400440532013000"} {
+		if strings.Contains(output, secret) {
+			t.Fatalf("%q was logged:\n%s", secret, output)
+		}
+	}
+	for _, masked := range []string{"j***@domain.com", "********78", "DE****************3000"} {
+		if !strings.Contains(output, masked) {
+			t.Fatalf("%q was not logged:\n%s", masked, output)
+		}
+	}
+}
+
+func TestLogLevels(t *testing.T) {
+	tests := []struct {
+		name    string
+		options []greip.Option
+		fail    bool
+		want    string
+	}{
+		{"default success", nil, false, ""},
+		{"default failure", nil, true, "WARN"},
+		{"custom success", []greip.Option{greip.WithLogLevels(slog.LevelInfo, slog.LevelError)}, false, "INFO"},
+		{"custom failure", []greip.Option{greip.WithLogLevels(slog.LevelInfo, slog.LevelError)}, true, "ERROR"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var logs bytes.Buffer
+			server := greiptest.NewServer()
+			defer server.Close()
+			if tt.fail {
+				server.Fail("IPLookup", greiptest.Failure{StatusCode: http.StatusUnauthorized, Description: "Invalid API key."})
+			}
+			logger := slog.New(slog.NewJSONHandler(&logs, &slog.HandlerOptions{Level: slog.LevelInfo}))
+			client := server.Client(append([]greip.Option{greip.WithLogger(logger)}, tt.options...)...)
+
+			if _, err := client.Lookup("1.1.1.1", nil); (err != nil) != tt.fail {
+				t.Fatalf("Lookup: %v", err)
+			}
+
+			var entry struct{ Level string }
+			if logs.Len() > 0 {
+				if err := json.Unmarshal(logs.Bytes(), &entry); err != nil {
+					t.Fatalf("got logs %s: %v", logs.String(), err)
+				}
+			}
+			if entry.Level != tt.want {
+				t.Fatalf("got logs %q, want one entry at level %q", logs.String(), tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,95 @@
+package greip
+
+import (
+	"math/big"
+	"net/netip"
+	"strconv"
+	"time"
+)
+
+// Addr returns the IP address of the lookup as a netip.Addr, with IPv4-mapped IPv6 addresses
+// unwrapped. It returns the zero Addr if the IP address can't be parsed.
+func (r *ResponseLookup) Addr() netip.Addr {
+	return parseAddr(r.IP)
+}
+
+// IPNumberBig returns the numeric value of the IP address, which may not fit in an int64
+// (as with most IPv6 addresses). It returns nil if the value is unknown.
+func (r *ResponseLookup) IPNumberBig() *big.Int {
+	if number, ok := parseBigInt(r.IPNumber.String()); ok {
+		return number
+	}
+
+	//? Fall back to the IP address itself
+	if addr := r.Addr(); addr.IsValid() {
+		return new(big.Int).SetBytes(addr.AsSlice())
+	}
+	return nil
+}
+
+// Coordinates returns the latitude and longitude of the IP address as numbers.
+// ok is false if the API didn't return valid coordinates.
+func (r *ResponseLookup) Coordinates() (lat, lon float64, ok bool) {
+	lat, latErr := strconv.ParseFloat(r.Latitude, 64)
+	lon, lonErr := strconv.ParseFloat(r.Longitude, 64)
+	//? Written as in-range checks so that NaN is rejected too
+	if latErr != nil || lonErr != nil || !(lat >= -90 && lat <= 90) || !(lon >= -180 && lon <= 180) {
+		return 0, 0, false
+	}
+	return lat, lon, true
+}
+
+// TimezoneLocation returns the time zone of the IP address, see LookupTimezone.Location.
+func (r *ResponseLookup) TimezoneLocation() *time.Location {
+	return r.Timezone.Location()
+}
+
+// Addr returns the IP address as a netip.Addr, with IPv4-mapped IPv6 addresses unwrapped.
+// It returns the zero Addr if the IP address can't be parsed.
+func (r *ResponseThreats) Addr() netip.Addr {
+	return parseAddr(r.IP)
+}
+
+// Location returns the time zone as a *time.Location, loaded from the IANA name when the
+// system knows it, otherwise built from the abbreviation and offset. It returns nil if the
+// time zone is unknown.
+func (tz LookupTimezone) Location() *time.Location {
+	if tz.Name != "" {
+		if location, err := time.LoadLocation(tz.Name); err == nil {
+			return location
+		}
+	}
+	if tz.Abbreviation == "" && tz.Offset == 0 {
+		return nil
+	}
+	name := tz.Abbreviation
+	if name == "" {
+		name = tz.Name
+	}
+	return time.FixedZone(name, tz.Offset)
+}
+
+// ? Helper function to parse an IP address returned by the API, ignoring invalid ones
+func parseAddr(ip string) netip.Addr {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return netip.Addr{}
+	}
+	return addr.Unmap()
+}
+
+// ? Helper function to parse an integer that may be written in decimal or exponent notation
+func parseBigInt(s string) (*big.Int, bool) {
+	if s == "" {
+		return nil, false
+	}
+	if number, ok := new(big.Int).SetString(s, 10); ok {
+		return number, true
+	}
+	f, ok := new(big.Float).SetPrec(256).SetString(s)
+	if !ok || f.IsInf() {
+		return nil, false
+	}
+	number, _ := f.Int(nil)
+	return number, true
+}
@@ -0,0 +1,146 @@
+package greip_test
+
+import (
+	"encoding/json"
+	"math/big"
+	"net/netip"
+	"testing"
+	"time"
+
+	greip "github.com/greipio/go"
+)
+
+func TestLookupAddr(t *testing.T) {
+	tests := []struct {
+		ip   string
+		want netip.Addr
+	}{
+		{"1.1.1.1", netip.MustParseAddr("1.1.1.1")},
+		{"2606:4700:4700::1111", netip.MustParseAddr("2606:4700:4700::1111")},
+		{"::ffff:1.1.1.1", netip.MustParseAddr("1.1.1.1")},
+		{"", netip.Addr{}},
+		{"1.1.1", netip.Addr{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.ip, func(t *testing.T) {
+			response := greip.ResponseLookup{IP: tt.ip}
+			if got := response.Addr(); got != tt.want {
+				t.Fatalf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLookupIPNumberBig(t *testing.T) {
+	tests := []struct {
+		name string
+		json string
+		want string
+	}{
+		{"IPv4", `{"ip":"1.1.1.1","IPNumber":16843009}`, "16843009"},
+		{"IPv6", `{"ip":"2606:4700:4700::1111","IPNumber":50543257694033307102031451402929180945}`, "50543257694033307102031451402929180945"},
+		{"exponent notation", `{"ip":"2606:4700:4700::1111","IPNumber":5.0543257694033307e+37}`, "50543257694033307000000000000000000000"},
+		{"quoted", `{"ip":"1.1.1.1","IPNumber":"16843009"}`, "16843009"},
+		{"missing", `{"ip":"2606:4700:4700::1111"}`, "50543257694033307102031451402929180945"},
+		{"null", `{"ip":"1.1.1.1","IPNumber":null}`, "16843009"},
+		{"unknown", `{"ip":"invalid"}`, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var response greip.ResponseLookup
+			if err := json.Unmarshal([]byte(tt.json), &response); err != nil {
+				t.Fatalf("Unmarshal: %v", err)
+			}
+			got := response.IPNumberBig()
+			if tt.want == "" {
+				if got != nil {
+					t.Fatalf("got %v, want nil", got)
+				}
+				return
+			}
+			want, _ := new(big.Int).SetString(tt.want, 10)
+			if got == nil || got.Cmp(want) != 0 {
+				t.Fatalf("got %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestLookupIPNumberRoundTrip(t *testing.T) {
+	data := []byte(`{"ip":"2606:4700:4700::1111","IPNumber":50543257694033307102031451402929180945,"countryCode":"US"}`)
+	var response greip.ResponseLookup
+	if err := json.Unmarshal(data, &response); err != nil {
+		t.Fatal(err)
+	}
+	encoded, err := json.Marshal(response)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded greip.ResponseLookup
+	if err := json.Unmarshal(encoded, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if decoded.IPNumber != response.IPNumber || decoded.CountryCode != "US" {
+		t.Fatalf("got %+v after a round trip, want %+v", decoded, response)
+	}
+}
+
+func TestLookupCoordinates(t *testing.T) {
+	tests := []struct {
+		name                string
+		latitude, longitude string
+		lat, lon            float64
+		ok                  bool
+	}{
+		{"valid", "48.8566", "2.3522", 48.8566, 2.3522, true},
+		{"bounds", "-90", "180", -90, 180, true},
+		{"empty", "", "", 0, 0, false},
+		{"empty longitude", "48.8566", "", 0, 0, false},
+		{"invalid", "north", "2.3522", 0, 0, false},
+		{"latitude out of range", "90.5", "2.3522", 0, 0, false},
+		{"longitude out of range", "48.8566", "-180.5", 0, 0, false},
+		{"not a number", "NaN", "2.3522", 0, 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			response := greip.ResponseLookup{Latitude: tt.latitude, Longitude: tt.longitude}
+			lat, lon, ok := response.Coordinates()
+			if lat != tt.lat || lon != tt.lon || ok != tt.ok {
+				t.Fatalf("got %v, %v, %v, want %v, %v, %v", lat, lon, ok, tt.lat, tt.lon, tt.ok)
+			}
+		})
+	}
+}
+
+func TestLookupTimezoneLocation(t *testing.T) {
+	tests := []struct {
+		name     string
+		timezone greip.LookupTimezone
+		want     string
+		offset   int
+	}{
+		{"IANA name", greip.LookupTimezone{Name: "UTC", Abbreviation: "GMT", Offset: 3600}, "UTC", 0},
+		{"unknown name", greip.LookupTimezone{Name: "Mars/Olympus_Mons", Abbreviation: "MOT", Offset: 7200}, "MOT", 7200},
+		{"unknown name without abbreviation", greip.LookupTimezone{Name: "Mars/Olympus_Mons", Offset: -3600}, "Mars/Olympus_Mons", -3600},
+		{"abbreviation only", greip.LookupTimezone{Abbreviation: "CET", Offset: 3600}, "CET", 3600},
+		{"empty", greip.LookupTimezone{}, "", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			response := greip.ResponseLookup{Timezone: tt.timezone}
+			location := response.TimezoneLocation()
+			if tt.want == "" {
+				if location != nil {
+					t.Fatalf("got %v, want nil", location)
+				}
+				return
+			}
+			if location == nil || location.String() != tt.want {
+				t.Fatalf("got %v, want %s", location, tt.want)
+			}
+			if _, offset := time.Date(2024, 1, 1, 0, 0, 0, 0, location).Zone(); offset != tt.offset {
+				t.Fatalf("got offset %d, want %d", offset, tt.offset)
+			}
+		})
+	}
+}
@@ -0,0 +1,161 @@
+package greip
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Meta holds the metadata returned along with a response: everything the API sends
+// besides the data itself.
+type Meta struct {
+	// ExecutionTime is the execution time reported by the API, as sent in the `executionTime` field.
+	ExecutionTime float64
+	// CreditsUsed is the number of credits consumed by the request, when reported by the API.
+	CreditsUsed int
+	// CreditsRemaining is the number of credits left in the plan quota, valid only if HasQuota is true.
+	CreditsRemaining int
+	// QuotaLimit is the total number of credits of the plan quota, when reported along with CreditsRemaining.
+	// It is valid only if HasQuota is true.
+	QuotaLimit int
+	// HasQuota reports whether the API reported the state of the quota in the response envelope.
+	HasQuota bool
+	// RateLimitLimit and RateLimitRemaining are the request rate limit window reported by the
+	// X-RateLimit-Limit and X-RateLimit-Remaining headers. They are valid only if HasRateLimit is true,
+	// which requires both headers, and are unrelated to the credits of the plan quota.
+	RateLimitLimit     int
+	RateLimitRemaining int
+	HasRateLimit       bool
+	// Extra holds the other fields of the response envelope, undecoded.
+	Extra map[string]json.RawMessage
+}
+
+// Usage is the running tally of the requests sent by a Greip instance.
+type Usage struct {
+	// Requests is the number of responses received from the API, successful or not.
+	Requests int64
+	// CreditsUsed is the total number of credits reported as consumed.
+	CreditsUsed int64
+	// CreditsRemaining and QuotaLimit are the last reported state of the quota, valid only if HasQuota is true.
+	CreditsRemaining int
+	QuotaLimit       int
+	HasQuota         bool
+	// UpdatedAt is the time of the last response.
+	UpdatedAt time.Time
+}
+
+// WithLowQuotaHandler calls handler every time a response reports that fewer than threshold
+// credits remain in the plan quota, e.g. to alert before it runs out. The handler must return quickly.
+func WithLowQuotaHandler(threshold int, handler func(usage Usage)) Option {
+	return func(g *Greip) {
+		g.usage.threshold = threshold
+		g.usage.handler = handler
+	}
+}
+
+// Usage returns the running tally of the requests sent by the instance.
+func (g *Greip) Usage() Usage {
+	g.usage.mu.Lock()
+	defer g.usage.mu.Unlock()
+	return g.usage.Usage
+}
+
+// ? usageTally accumulates the metadata of the responses
+type usageTally struct {
+	mu sync.Mutex
+	Usage
+
+	threshold int
+	handler   func(usage Usage)
+}
+
+// ? Helper function to add the metadata of a response to the tally, calling the low-quota handler if needed
+func (g *Greip) recordUsage(meta Meta) {
+	g.usage.mu.Lock()
+	g.usage.Requests++
+	g.usage.CreditsUsed += int64(meta.CreditsUsed)
+	if meta.HasQuota {
+		g.usage.CreditsRemaining = meta.CreditsRemaining
+		g.usage.QuotaLimit = meta.QuotaLimit
+		g.usage.HasQuota = true
+	}
+	g.usage.UpdatedAt = time.Now()
+	usage := g.usage.Usage
+	g.usage.mu.Unlock()
+
+	if g.usage.handler != nil && meta.HasQuota && meta.CreditsRemaining < g.usage.threshold {
+		g.usage.handler(usage)
+	}
+}
+
+// ? Fields of the envelope that are not metadata
+var envelopeFields = map[string]bool{"status": true, "data": true, "description": true, "code": true, "type": true}
+
+// ? Helper function to extract the metadata of a response from its envelope and headers
+func parseMeta(resp *http.Response, envelope map[string]json.RawMessage) Meta {
+	var meta Meta
+	var quotaLimit json.RawMessage
+
+	for key, value := range envelope {
+		switch key {
+		case "executionTime":
+			meta.ExecutionTime, _ = strconv.ParseFloat(rawString(value), 64)
+		case "credits", "creditsUsed":
+			meta.CreditsUsed, _ = strconv.Atoi(rawString(value))
+		case "creditsRemaining":
+			if remaining, err := strconv.Atoi(rawString(value)); err == nil {
+				meta.CreditsRemaining, meta.HasQuota = remaining, true
+			}
+		case "creditsLimit":
+			quotaLimit = value
+		default:
+			if !envelopeFields[key] {
+				meta.addExtra(key, value)
+			}
+		}
+	}
+
+	//? The limit only means something along with the remaining credits, otherwise it is kept undecoded
+	if quotaLimit != nil {
+		if limit, err := strconv.Atoi(rawString(quotaLimit)); err == nil && meta.HasQuota {
+			meta.QuotaLimit = limit
+		} else {
+			meta.addExtra("creditsLimit", quotaLimit)
+		}
+	}
+
+	//? The rate limit headers describe the request rate, not the credits of the plan
+	if resp != nil {
+		limit, limitErr := strconv.Atoi(resp.Header.Get("X-RateLimit-Limit"))
+		remaining, remainingErr := strconv.Atoi(resp.Header.Get("X-RateLimit-Remaining"))
+		if limitErr == nil && remainingErr == nil {
+			meta.RateLimitLimit, meta.RateLimitRemaining, meta.HasRateLimit = limit, remaining, true
+		}
+	}
+	return meta
+}
+
+// ? Helper function to keep an envelope field undecoded in Extra
+func (m *Meta) addExtra(key string, value json.RawMessage) {
+	if m.Extra == nil {
+		m.Extra = make(map[string]json.RawMessage)
+	}
+	m.Extra[key] = value
+}
+
+// ? metaSetter is implemented by the response types exposing the metadata of the response
+type metaSetter interface {
+	setMeta(meta Meta)
+}
+
+func (r *ResponseLookup) setMeta(meta Meta)    { r.Meta = meta }
+func (r *ResponseThreats) setMeta(meta Meta)   { r.Meta = meta }
+func (r *ResponseCountry) setMeta(meta Meta)   { r.Meta = meta }
+func (r *ResponseProfanity) setMeta(meta Meta) { r.Meta = meta }
+func (r *ResponseASN) setMeta(meta Meta)       { r.Meta = meta }
+func (r *ResponseEmail) setMeta(meta Meta)     { r.Meta = meta }
+func (r *ResponsePhone) setMeta(meta Meta)     { r.Meta = meta }
+func (r *ResponseIBAN) setMeta(meta Meta)      { r.Meta = meta }
+func (r *ResponsePayment) setMeta(meta Meta)   { r.Meta = meta }
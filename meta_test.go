@@ -0,0 +1,136 @@
+package greip_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"reflect"
+	"testing"
+
+	greip "github.com/greipio/go"
+	"github.com/greipio/go/greiptest"
+)
+
+func TestMeta(t *testing.T) {
+	tests := []struct {
+		name     string
+		envelope map[string]interface{}
+		header   http.Header
+		want     greip.Meta
+		extra    string
+	}{
+		{
+			name:   "rate limit headers are not quota",
+			header: http.Header{"X-Ratelimit-Limit": {"100"}, "X-Ratelimit-Remaining": {"99"}},
+			want:   greip.Meta{RateLimitLimit: 100, RateLimitRemaining: 99, HasRateLimit: true},
+		},
+		{
+			name:   "rate limit without remaining requests",
+			header: http.Header{"X-Ratelimit-Limit": {"100"}},
+			want:   greip.Meta{},
+		},
+		{
+			name:   "rate limit without limit",
+			header: http.Header{"X-Ratelimit-Remaining": {"99"}},
+			want:   greip.Meta{},
+		},
+		{
+			name:   "invalid rate limit",
+			header: http.Header{"X-Ratelimit-Limit": {"100"}, "X-Ratelimit-Remaining": {"many"}},
+			want:   greip.Meta{},
+		},
+		{
+			name:     "quota",
+			envelope: map[string]interface{}{"executionTime": "0.02", "creditsUsed": 1, "creditsRemaining": 500, "creditsLimit": 1000},
+			want:     greip.Meta{ExecutionTime: 0.02, CreditsUsed: 1, CreditsRemaining: 500, QuotaLimit: 1000, HasQuota: true},
+		},
+		{
+			name:     "limit without remaining credits",
+			envelope: map[string]interface{}{"creditsLimit": 1000, "region": "eu"},
+			want:     greip.Meta{},
+			extra:    "creditsLimit",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := greiptest.NewServer()
+			defer server.Close()
+			client := server.Client(greip.WithMiddleware(injectMeta(tt.envelope, tt.header)))
+
+			response, err := client.Lookup("1.1.1.1", nil)
+			if err != nil {
+				t.Fatalf("Lookup: %v", err)
+			}
+			meta := response.Meta
+			if tt.extra != "" {
+				if _, ok := meta.Extra[tt.extra]; !ok {
+					t.Fatalf("got Extra %v, want it to hold %s", meta.Extra, tt.extra)
+				}
+			}
+			meta.Extra = nil
+			if !reflect.DeepEqual(meta, tt.want) {
+				t.Fatalf("got %+v, want %+v", meta, tt.want)
+			}
+			if usage := client.Usage(); usage.HasQuota != tt.want.HasQuota || usage.QuotaLimit != tt.want.QuotaLimit {
+				t.Fatalf("got usage %+v, want the quota of %+v", usage, tt.want)
+			}
+		})
+	}
+}
+
+func TestBulkLookupMeta(t *testing.T) {
+	server := greiptest.NewServer()
+	defer server.Close()
+	client := server.Client(
+		greip.WithBulkChunkSize(2),
+		greip.WithMiddleware(injectMeta(map[string]interface{}{"creditsUsed": 2, "creditsRemaining": 10}, nil)),
+	)
+
+	response, err := client.BulkLookup([]string{"1.1.1.1", "1.1.1.2", "1.1.1.3"}, nil)
+	if err != nil {
+		t.Fatalf("BulkLookup: %v", err)
+	}
+	for ip, result := range *response {
+		if result.Meta.CreditsUsed != 2 || !result.Meta.HasQuota || result.Meta.CreditsRemaining != 10 {
+			t.Fatalf("got meta %+v for %s, want the metadata of its chunk", result.Meta, ip)
+		}
+	}
+}
+
+// ? Helper function to create a middleware adding fields to the response envelope and headers to the response
+func injectMeta(envelope map[string]interface{}, header http.Header) greip.Middleware {
+	return func(next greip.RoundTripFunc) greip.RoundTripFunc {
+		return func(call *greip.Call) (*http.Response, error) {
+			resp, err := next(call)
+			if err != nil {
+				return resp, err
+			}
+			//? The response is replaced rather than modified, so that the client decodes it again
+			replaced := *resp
+			replaced.Header = resp.Header.Clone()
+			for key, values := range header {
+				replaced.Header[key] = values
+			}
+
+			var fields map[string]interface{}
+			body, err := io.ReadAll(resp.Body)
+			resp.Body.Close()
+			if err != nil {
+				return nil, err
+			}
+			if err := json.Unmarshal(body, &fields); err != nil {
+				return nil, err
+			}
+			for key, value := range envelope {
+				fields[key] = value
+			}
+			if body, err = json.Marshal(fields); err != nil {
+				return nil, err
+			}
+			replaced.Body = io.NopCloser(bytes.NewReader(body))
+			replaced.ContentLength = int64(len(body))
+			return &replaced, nil
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package greip
+
+import "net/http"
+
+// Call describes an attempt to call an endpoint of the Greip API, as seen by middlewares.
+type Call struct {
+	// Endpoint is the name of the called endpoint (e.g. "IPLookup", "paymentFraud").
+	Endpoint string
+	// Method is the HTTP method of the request.
+	Method string
+	// Payload holds the parameters of the request, sent as query parameters for GET requests
+	// and as the JSON body for POST requests. It must not be modified.
+	Payload map[string]interface{}
+	// Request is the outgoing HTTP request. Middlewares may modify its headers.
+	Request *http.Request
+	// Attempt is the number of the attempt, starting at 1, when requests are retried.
+	Attempt int
+
+	result *result
+}
+
+// RoundTripFunc performs a call and returns the HTTP response along with the decoded error:
+// an *APIError when the API rejected the request, or the network error. The response body
+// has already been read and can be read again.
+type RoundTripFunc func(call *Call) (*http.Response, error)
+
+// Middleware wraps a RoundTripFunc to run code around every outgoing call, e.g. for logging,
+// metrics or header injection. A middleware must call next to send the request, unless it
+// wants to short-circuit it.
+//
+// Example usage:
+//
+//	timing := func(next greip.RoundTripFunc) greip.RoundTripFunc {
+//	    return func(call *greip.Call) (*http.Response, error) {
+//	        start := time.Now()
+//	        resp, err := next(call)
+//	        log.Printf("%s took %s (attempt %d, error: %v)", call.Endpoint, time.Since(start), call.Attempt, err)
+//	        return resp, err
+//	    }
+//	}
+//	greipInstance := greip.NewClient("YOUR_API_TOKEN", greip.WithMiddleware(timing))
+type Middleware func(next RoundTripFunc) RoundTripFunc
+
+// WithMiddleware adds middlewares around every outgoing call. Middlewares run in the order
+// they are given, the first one being the outermost. Each retry attempt goes through the
+// whole chain, while responses served from the cache or shared by coalesced calls don't.
+func WithMiddleware(middlewares ...Middleware) Option {
+	return func(g *Greip) {
+		g.middlewares = append(g.middlewares, middlewares...)
+	}
+}
+
+// ? Helper function to build the middleware chain around the HTTP round trip
+func (g *Greip) buildChain() {
+	next := RoundTripFunc(g.roundTrip)
+	for i := len(g.middlewares) - 1; i >= 0; i-- {
+		next = g.middlewares[i](next)
+	}
+	g.roundTripper = next
+}
+
+// ? Helper function to get the middleware chain, falling back to the bare round trip for zero-value instances
+func (g *Greip) chain() RoundTripFunc {
+	if g.roundTripper == nil {
+		return g.roundTrip
+	}
+	return g.roundTripper
+}
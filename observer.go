@@ -0,0 +1,81 @@
+package greip
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// CallEvent describes a call to an endpoint, reported to observers once it completes.
+type CallEvent struct {
+	// Endpoint is the name of the called endpoint (e.g. "IPLookup", "paymentFraud").
+	Endpoint string
+	// Test reports whether the call was made in test mode.
+	Test bool
+	// Attempt is the number of the attempt, starting at 1. It is zero for calls served
+	// without sending a request, from the cache or from a coalesced call.
+	Attempt int
+	// StatusCode is the HTTP status code of the response, zero if none was received.
+	StatusCode int
+	// Latency is the duration of the attempt.
+	Latency time.Duration
+	// CacheHit reports whether the response was served from the cache.
+	CacheHit bool
+	// Coalesced reports whether the response was shared by an identical call in flight.
+	Coalesced bool
+	// Err is the error of the attempt, if any.
+	Err error
+}
+
+// Observer is notified of every call to the Greip API, including the ones served from the cache
+// or coalesced with identical calls. Implementations must be safe for concurrent use and return quickly.
+type Observer interface {
+	ObserveCall(event CallEvent)
+}
+
+// ObserverFunc is an adapter to use an ordinary function as an Observer.
+type ObserverFunc func(event CallEvent)
+
+func (f ObserverFunc) ObserveCall(event CallEvent) {
+	f(event)
+}
+
+// WithObserver adds an observer notified of every call, e.g. to collect metrics.
+func WithObserver(observer Observer) Option {
+	return func(g *Greip) {
+		g.observers = append(g.observers, observer)
+	}
+}
+
+// ? Helper function to report an attempt to call the API to the logger and the observers
+func (g *Greip) observeAttempt(ctx context.Context, call Call, resp *http.Response, err error, latency time.Duration) {
+	event := CallEvent{
+		Endpoint: call.Endpoint,
+		Test:     g.test,
+		Attempt:  call.Attempt,
+		Latency:  latency,
+		Err:      err,
+	}
+	if resp != nil {
+		event.StatusCode = resp.StatusCode
+	}
+	g.logAttempt(ctx, call, resp, err, latency)
+	g.notify(event)
+}
+
+// ? Helper function to report a response served without calling the API to the logger and the observers
+func (g *Greip) observeShared(ctx context.Context, endpoint string, cacheHit bool, payload map[string]interface{}) {
+	g.logShared(ctx, endpoint, cacheHit, payload)
+	g.notify(CallEvent{
+		Endpoint:  endpoint,
+		Test:      g.test,
+		CacheHit:  cacheHit,
+		Coalesced: !cacheHit,
+	})
+}
+
+func (g *Greip) notify(event CallEvent) {
+	for _, observer := range g.observers {
+		observer.ObserveCall(event)
+	}
+}
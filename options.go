@@ -0,0 +1,77 @@
+package greip
+
+import (
+	"net/http"
+	"time"
+)
+
+// ? Default timeout applied to the HTTP client when none is configured
+const defaultTimeout = 30 * time.Second
+
+// Option configures a Greip instance. Options are passed to NewClient after the API token.
+type Option func(*Greip)
+
+// WithHTTPClient makes the Greip instance send its requests through client instead of
+// the default one. The client is copied, so WithTimeout and WithTransport never modify it.
+func WithHTTPClient(client *http.Client) Option {
+	return func(g *Greip) {
+		g.httpClient = client
+	}
+}
+
+// WithTimeout sets the overall timeout of every HTTP request, including reading the response body.
+func WithTimeout(timeout time.Duration) Option {
+	return func(g *Greip) {
+		g.timeout = timeout
+	}
+}
+
+// WithTransport sets the http.RoundTripper used to send requests, which is where proxies,
+// mTLS certificates and connection pool settings live.
+func WithTransport(transport http.RoundTripper) Option {
+	return func(g *Greip) {
+		g.transport = transport
+	}
+}
+
+// WithBaseURL overrides the Greip API base URL (https://greipapi.com/ by default).
+// A trailing slash is added when missing.
+func WithBaseURL(baseURL string) Option {
+	return func(g *Greip) {
+		if baseURL != "" && baseURL[len(baseURL)-1] != '/' {
+			baseURL += "/"
+		}
+		g.BaseURL = baseURL
+	}
+}
+
+// WithTestMode enables or disables the test mode, in which the API returns fake data
+// without affecting the subscription usage.
+func WithTestMode(test bool) Option {
+	return func(g *Greip) {
+		g.test = test
+	}
+}
+
+// WithUserAgent sets the User-Agent header sent with every request.
+func WithUserAgent(userAgent string) Option {
+	return func(g *Greip) {
+		g.userAgent = userAgent
+	}
+}
+
+// ? Helper function to build the shared HTTP client out of the configured options
+func (g *Greip) buildHTTPClient() {
+	client := &http.Client{Timeout: defaultTimeout}
+	if g.httpClient != nil {
+		copied := *g.httpClient
+		client = &copied
+	}
+	if g.timeout > 0 {
+		client.Timeout = g.timeout
+	}
+	if g.transport != nil {
+		client.Transport = g.transport
+	}
+	g.httpClient = client
+}
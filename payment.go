@@ -0,0 +1,213 @@
+package greip
+
+import (
+	"context"
+	"fmt"
+	"net/netip"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// PaymentRequest is the data checked by PaymentTyped. Every field is optional, but the more
+// fields are provided, the more accurate the fraud score is. Fields left to their zero value
+// are not sent.
+type PaymentRequest struct {
+	// Action is the type of transaction: "purchase", "deposit" or "withdrawal".
+	Action      string `json:"action,omitempty"`
+	WebsiteURL  string `json:"website_url,omitempty"`
+	WebsiteName string `json:"website_name,omitempty"`
+	MerchantID  string `json:"merchant_id,omitempty"`
+	ShipmentID  string `json:"shipment_id,omitempty"`
+
+	// Transaction
+	TransactionID     string  `json:"transaction_id,omitempty"`
+	TransactionAmount float64 `json:"transaction_amount,omitempty"`
+	// TransactionCurrency is the ISO 4217 currency code of the amount (e.g. "USD").
+	TransactionCurrency string            `json:"transaction_currency,omitempty"`
+	CartItems           []PaymentCartItem `json:"cart_items,omitempty"`
+	IsDigitalProducts   *bool             `json:"isDigitalProducts,omitempty"`
+	Coupon              string            `json:"coupon,omitempty"`
+
+	// Customer
+	CustomerID        string `json:"customer_id,omitempty"`
+	CustomerFirstName string `json:"customer_firstname,omitempty"`
+	CustomerLastName  string `json:"customer_lastname,omitempty"`
+	// CustomerPlaceOfBirth is the ISO 3166-1 alpha-2 country code of the customer's place of birth.
+	CustomerPlaceOfBirth string `json:"customer_pob,omitempty"`
+	CustomerIP           string `json:"customer_ip,omitempty"`
+	// CustomerCountry is the ISO 3166-1 alpha-2 country code of the customer's address.
+	CustomerCountry   string  `json:"customer_country,omitempty"`
+	CustomerRegion    string  `json:"customer_region,omitempty"`
+	CustomerCity      string  `json:"customer_city,omitempty"`
+	CustomerZip       string  `json:"customer_zip,omitempty"`
+	CustomerStreet    string  `json:"customer_street,omitempty"`
+	CustomerStreet2   string  `json:"customer_street2,omitempty"`
+	CustomerLatitude  float64 `json:"customer_latitude,omitempty"`
+	CustomerLongitude float64 `json:"customer_longitude,omitempty"`
+	CustomerDeviceID  string  `json:"customer_device_id,omitempty"`
+	CustomerPhone     string  `json:"customer_phone,omitempty"`
+	// CustomerRegistrationDate is the Unix timestamp at which the customer registered.
+	CustomerRegistrationDate int64   `json:"customer_registration_date,omitempty"`
+	CustomerBalance          float64 `json:"customer_balance,omitempty"`
+	// CustomerDateOfBirth is the customer's date of birth, formatted as YYYY-MM-DD.
+	CustomerDateOfBirth string `json:"customer_dob,omitempty"`
+	CustomerEmail       string `json:"customer_email,omitempty"`
+	// Customer2FA reports whether the customer has two-factor authentication enabled.
+	Customer2FA       *bool  `json:"customer_2fa,omitempty"`
+	CustomerUserAgent string `json:"customer_useragent,omitempty"`
+
+	// Shipping address
+	ShippingCountry   string  `json:"shipping_country,omitempty"`
+	ShippingRegion    string  `json:"shipping_region,omitempty"`
+	ShippingCity      string  `json:"shipping_city,omitempty"`
+	ShippingZip       string  `json:"shipping_zip,omitempty"`
+	ShippingStreet    string  `json:"shipping_street,omitempty"`
+	ShippingStreet2   string  `json:"shipping_street2,omitempty"`
+	ShippingLatitude  float64 `json:"shipping_latitude,omitempty"`
+	ShippingLongitude float64 `json:"shipping_longitude,omitempty"`
+
+	// Billing address
+	BillingCountry   string  `json:"billing_country,omitempty"`
+	BillingRegion    string  `json:"billing_region,omitempty"`
+	BillingCity      string  `json:"billing_city,omitempty"`
+	BillingZip       string  `json:"billing_zip,omitempty"`
+	BillingStreet    string  `json:"billing_street,omitempty"`
+	BillingStreet2   string  `json:"billing_street2,omitempty"`
+	BillingLatitude  float64 `json:"billing_latitude,omitempty"`
+	BillingLongitude float64 `json:"billing_longitude,omitempty"`
+
+	// Payment method
+	PaymentType string `json:"payment_type,omitempty"`
+	CardName    string `json:"card_name,omitempty"`
+	// CardNumber is the card BIN (its first 6 to 8 digits) or the full card number.
+	CardNumber string `json:"card_number,omitempty"`
+	// CardExpiry is the expiry date of the card, formatted as MM/YY.
+	CardExpiry string `json:"card_expiry,omitempty"`
+	// CVVResult reports whether the CVV check succeeded.
+	CVVResult *bool `json:"cvv_result,omitempty"`
+}
+
+// PaymentCartItem is an item of the cart of a PaymentRequest.
+type PaymentCartItem struct {
+	ItemID         string  `json:"item_id,omitempty"`
+	ItemName       string  `json:"item_name,omitempty"`
+	ItemQuantity   int     `json:"item_quantity,omitempty"`
+	ItemPrice      float64 `json:"item_price,omitempty"`
+	ItemCategoryID string  `json:"item_category_id,omitempty"`
+}
+
+var (
+	paymentActions      = []string{"purchase", "deposit", "withdrawal"}
+	countryCodePattern  = regexp.MustCompile(`^[A-Za-z]{2}$`)
+	currencyCodePattern = regexp.MustCompile(`^[A-Za-z]{3}$`)
+	cardNumberPattern   = regexp.MustCompile(`^[0-9]{6,19}$`)
+	cardExpiryPattern   = regexp.MustCompile(`^(0[1-9]|1[0-2])/[0-9]{2}$`)
+)
+
+// Validate checks the fields of the request before it is sent, and returns a *ValidationError
+// describing the first invalid field.
+func (r PaymentRequest) Validate() error {
+	invalid := func(field, value, reason string) error {
+		return &ValidationError{
+			Field:   field,
+			Value:   value,
+			Message: fmt.Sprintf("invalid `%s` parameter: %s", field, reason),
+		}
+	}
+
+	if r.Action != "" && !contains(paymentActions, r.Action) {
+		return invalid("action", r.Action, "must be one of "+strings.Join(paymentActions, ", "))
+	}
+	if r.TransactionAmount < 0 {
+		return invalid("transaction_amount", fmt.Sprint(r.TransactionAmount), "must not be negative")
+	}
+	if r.TransactionCurrency != "" && !currencyCodePattern.MatchString(r.TransactionCurrency) {
+		return invalid("transaction_currency", r.TransactionCurrency, "must be an ISO 4217 currency code")
+	}
+	for _, item := range r.CartItems {
+		if item.ItemQuantity < 0 || item.ItemPrice < 0 {
+			return invalid("cart_items", item.ItemID, "quantities and prices must not be negative")
+		}
+	}
+	if r.CustomerIP != "" {
+		if _, err := netip.ParseAddr(r.CustomerIP); err != nil {
+			return invalid("customer_ip", r.CustomerIP, "must be an IPv4 or IPv6 address")
+		}
+	}
+	if r.CustomerEmail != "" {
+		if at := strings.LastIndex(r.CustomerEmail, "@"); at < 1 || at == len(r.CustomerEmail)-1 {
+			return invalid("customer_email", r.CustomerEmail, "must be an email address")
+		}
+	}
+	if r.CustomerDateOfBirth != "" {
+		if _, err := time.Parse(time.DateOnly, r.CustomerDateOfBirth); err != nil {
+			return invalid("customer_dob", r.CustomerDateOfBirth, "must be formatted as YYYY-MM-DD")
+		}
+	}
+	if r.CustomerRegistrationDate < 0 {
+		return invalid("customer_registration_date", fmt.Sprint(r.CustomerRegistrationDate), "must be a Unix timestamp")
+	}
+
+	for _, country := range []struct{ field, code string }{
+		{"customer_pob", r.CustomerPlaceOfBirth},
+		{"customer_country", r.CustomerCountry},
+		{"shipping_country", r.ShippingCountry},
+		{"billing_country", r.BillingCountry},
+	} {
+		if country.code != "" && !countryCodePattern.MatchString(country.code) {
+			return invalid(country.field, country.code, "must be an ISO 3166-1 alpha-2 country code")
+		}
+	}
+
+	for _, point := range []struct {
+		prefix              string
+		latitude, longitude float64
+	}{
+		{"customer", r.CustomerLatitude, r.CustomerLongitude},
+		{"shipping", r.ShippingLatitude, r.ShippingLongitude},
+		{"billing", r.BillingLatitude, r.BillingLongitude},
+	} {
+		if point.latitude < -90 || point.latitude > 90 {
+			return invalid(point.prefix+"_latitude", fmt.Sprint(point.latitude), "must be between -90 and 90")
+		}
+		if point.longitude < -180 || point.longitude > 180 {
+			return invalid(point.prefix+"_longitude", fmt.Sprint(point.longitude), "must be between -180 and 180")
+		}
+	}
+
+	if r.CardNumber != "" && !cardNumberPattern.MatchString(r.CardNumber) {
+		return invalid("card_number", "", "must contain 6 to 19 digits")
+	}
+	if r.CardExpiry != "" && !cardExpiryPattern.MatchString(r.CardExpiry) {
+		return invalid("card_expiry", r.CardExpiry, "must be formatted as MM/YY")
+	}
+	return nil
+}
+
+// PaymentTyped is like PaymentContext but takes a PaymentRequest, which is validated
+// before being sent.
+//
+// Example usage:
+//
+//	response, err := greipInstance.PaymentTyped(ctx, greip.PaymentRequest{
+//	    Action:              "purchase",
+//	    CustomerID:          "123456",
+//	    CustomerEmail:       "name@domain.com",
+//	    CustomerIP:          "1.1.1.1",
+//	    TransactionAmount:   49.90,
+//	    TransactionCurrency: "USD",
+//	})
+//	if err != nil {
+//	    log.Fatalf("Error performing payment fraud detection: %v", err)
+//	}
+//	fmt.Printf("Payment Fraud Detection Result: %+v\n", response)
+func (g *Greip) PaymentTyped(ctx context.Context, request PaymentRequest) (*ResponsePayment, error) {
+	//? Validate the request
+	if err := request.Validate(); err != nil {
+		return nil, err
+	}
+
+	//? The request is encoded as is, its empty fields being left out by their tags
+	return g.payment(ctx, request)
+}
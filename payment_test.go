@@ -0,0 +1,155 @@
+package greip_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sort"
+	"testing"
+
+	greip "github.com/greipio/go"
+	"github.com/greipio/go/greiptest"
+)
+
+func TestPaymentRequestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		request greip.PaymentRequest
+		field   string
+	}{
+		{"empty", greip.PaymentRequest{}, ""},
+		{"valid", greip.PaymentRequest{
+			Action: "purchase", TransactionAmount: 49.9, TransactionCurrency: "USD",
+			CartItems:  []greip.PaymentCartItem{{ItemID: "1", ItemQuantity: 2, ItemPrice: 10}},
+			CustomerIP: "2001:db8::1", CustomerEmail: "name@domain.com", CustomerDateOfBirth: "1990-01-31",
+			CustomerRegistrationDate: 1700000000, CustomerPlaceOfBirth: "FR", CustomerCountry: "fr",
+			ShippingCountry: "DE", BillingCountry: "US",
+			CustomerLatitude: -90, CustomerLongitude: 180, ShippingLatitude: 90, ShippingLongitude: -180,
+			CardNumber: "411111", CardExpiry: "12/29",
+		}, ""},
+		{"action", greip.PaymentRequest{Action: "refund"}, "action"},
+		{"transaction amount", greip.PaymentRequest{TransactionAmount: -1}, "transaction_amount"},
+		{"transaction currency", greip.PaymentRequest{TransactionCurrency: "US"}, "transaction_currency"},
+		{"cart item quantity", greip.PaymentRequest{CartItems: []greip.PaymentCartItem{{ItemQuantity: -1}}}, "cart_items"},
+		{"cart item price", greip.PaymentRequest{CartItems: []greip.PaymentCartItem{{ItemPrice: -1}}}, "cart_items"},
+		{"customer IP", greip.PaymentRequest{CustomerIP: "1.1.1"}, "customer_ip"},
+		{"customer email without @", greip.PaymentRequest{CustomerEmail: "name"}, "customer_email"},
+		{"customer email without name", greip.PaymentRequest{CustomerEmail: "@domain.com"}, "customer_email"},
+		{"customer email without domain", greip.PaymentRequest{CustomerEmail: "name@"}, "customer_email"},
+		{"customer date of birth", greip.PaymentRequest{CustomerDateOfBirth: "31/01/1990"}, "customer_dob"},
+		{"customer registration date", greip.PaymentRequest{CustomerRegistrationDate: -1}, "customer_registration_date"},
+		{"customer place of birth", greip.PaymentRequest{CustomerPlaceOfBirth: "FRA"}, "customer_pob"},
+		{"customer country", greip.PaymentRequest{CustomerCountry: "F1"}, "customer_country"},
+		{"shipping country", greip.PaymentRequest{ShippingCountry: "D"}, "shipping_country"},
+		{"billing country", greip.PaymentRequest{BillingCountry: "USA"}, "billing_country"},
+		{"customer latitude", greip.PaymentRequest{CustomerLatitude: 90.1}, "customer_latitude"},
+		{"customer longitude", greip.PaymentRequest{CustomerLongitude: -180.1}, "customer_longitude"},
+		{"shipping latitude", greip.PaymentRequest{ShippingLatitude: -91}, "shipping_latitude"},
+		{"shipping longitude", greip.PaymentRequest{ShippingLongitude: 181}, "shipping_longitude"},
+		{"billing latitude", greip.PaymentRequest{BillingLatitude: 91}, "billing_latitude"},
+		{"billing longitude", greip.PaymentRequest{BillingLongitude: -181}, "billing_longitude"},
+		{"card number too short", greip.PaymentRequest{CardNumber: "41111"}, "card_number"},
+		{"card number with spaces", greip.PaymentRequest{CardNumber: "4111 1111 1111 1111"}, "card_number"},
+		{"card expiry", greip.PaymentRequest{CardExpiry: "13/29"}, "card_expiry"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.request.Validate()
+			if tt.field == "" {
+				if err != nil {
+					t.Fatalf("got %v, want the request to be valid", err)
+				}
+				return
+			}
+			var validationErr *greip.ValidationError
+			if !errors.As(err, &validationErr) || validationErr.Field != tt.field {
+				t.Fatalf("got %v, want a *ValidationError on %s", err, tt.field)
+			}
+			//? Card numbers are never echoed back in errors
+			if tt.field == "card_number" && validationErr.Value != "" {
+				t.Fatalf("got value %q in the error, want it to be left out", validationErr.Value)
+			}
+		})
+	}
+}
+
+func TestPaymentTyped(t *testing.T) {
+	server := greiptest.NewServer()
+	defer server.Close()
+	client := server.Client()
+	verified := false
+
+	_, err := client.PaymentTyped(context.Background(), greip.PaymentRequest{
+		Action:                   "purchase",
+		WebsiteURL:               "https://shop.example",
+		TransactionID:            "T1",
+		TransactionAmount:        49.9,
+		TransactionCurrency:      "USD",
+		CartItems:                []greip.PaymentCartItem{{ItemID: "1", ItemName: "Book", ItemQuantity: 2, ItemPrice: 24.95, ItemCategoryID: "3"}},
+		IsDigitalProducts:        &verified,
+		CustomerID:               "42",
+		CustomerFirstName:        "John",
+		CustomerPlaceOfBirth:     "FR",
+		CustomerIP:               "1.1.1.1",
+		CustomerLatitude:         48.85,
+		CustomerRegistrationDate: 1700000000,
+		CustomerDateOfBirth:      "1990-01-31",
+		CustomerEmail:            "name@domain.com",
+		Customer2FA:              &verified,
+		CustomerUserAgent:        "Mozilla/5.0",
+		ShippingCountry:          "DE",
+		BillingStreet2:           "Floor 2",
+		PaymentType:              "card",
+		CardNumber:               "411111",
+		CardExpiry:               "12/29",
+		CVVResult:                &verified,
+	})
+	if err != nil {
+		t.Fatalf("PaymentTyped: %v", err)
+	}
+
+	requests := server.RequestsTo("paymentFraud")
+	if len(requests) != 1 {
+		t.Fatalf("got %d requests, want 1", len(requests))
+	}
+	data, _ := requests[0].Body["data"].(map[string]interface{})
+	var fields []string
+	for field := range data {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+
+	//? Only the fields that were set are sent, under the names expected by the API
+	want := []string{
+		"action", "billing_street2", "card_expiry", "card_number", "cart_items", "customer_2fa", "customer_dob",
+		"customer_email", "customer_firstname", "customer_id", "customer_ip", "customer_latitude", "customer_pob",
+		"customer_registration_date", "customer_useragent", "cvv_result", "isDigitalProducts", "payment_type",
+		"shipping_country", "transaction_amount", "transaction_currency", "transaction_id", "website_url",
+	}
+	if !reflect.DeepEqual(fields, want) {
+		t.Fatalf("got fields %q, want %q", fields, want)
+	}
+	wantItems := []interface{}{map[string]interface{}{
+		"item_id": "1", "item_name": "Book", "item_quantity": 2.0, "item_price": 24.95, "item_category_id": "3",
+	}}
+	if !reflect.DeepEqual(data["cart_items"], wantItems) {
+		t.Fatalf("got cart items %v, want %v", data["cart_items"], wantItems)
+	}
+	if data["customer_2fa"] != false || data["transaction_amount"] != 49.9 || data["customer_registration_date"] != 1700000000.0 {
+		t.Fatalf("got %v, want the values of the request", data)
+	}
+}
+
+func TestPaymentTypedInvalid(t *testing.T) {
+	server := greiptest.NewServer()
+	defer server.Close()
+
+	_, err := server.Client().PaymentTyped(context.Background(), greip.PaymentRequest{CustomerIP: "invalid"})
+	var validationErr *greip.ValidationError
+	if !errors.As(err, &validationErr) || validationErr.Field != "customer_ip" {
+		t.Fatalf("got %v, want a *ValidationError on customer_ip", err)
+	}
+	if got := len(server.Requests()); got != 0 {
+		t.Fatalf("got %d requests, want none", got)
+	}
+}
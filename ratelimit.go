@@ -0,0 +1,190 @@
+package greip
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// WithRateLimit limits the client to rps requests per second across all endpoints,
+// allowing bursts of up to burst requests. Calls exceeding the limit block until
+// a token is available or their context is done.
+func WithRateLimit(rps float64, burst int) Option {
+	return func(g *Greip) {
+		g.limits.rate = newTokenBucket(rps, burst)
+	}
+}
+
+// WithEndpointRateLimit is like WithRateLimit but only applies to the given endpoint
+// (e.g. "IPLookup", "threats", "paymentFraud"), on top of the client-wide limit.
+func WithEndpointRateLimit(endpoint string, rps float64, burst int) Option {
+	return func(g *Greip) {
+		g.limits.endpoint(endpoint).rate = newTokenBucket(rps, burst)
+	}
+}
+
+// WithMaxConcurrency caps the number of requests in flight at the same time across all endpoints.
+// Calls exceeding the cap block until a request completes or their context is done.
+func WithMaxConcurrency(n int) Option {
+	return func(g *Greip) {
+		g.limits.inFlight = newSemaphore(n)
+	}
+}
+
+// WithEndpointMaxConcurrency is like WithMaxConcurrency but only applies to the given endpoint,
+// on top of the client-wide cap.
+func WithEndpointMaxConcurrency(endpoint string, n int) Option {
+	return func(g *Greip) {
+		g.limits.endpoint(endpoint).inFlight = newSemaphore(n)
+	}
+}
+
+// ? limiter throttles requests with an optional token bucket and an optional in-flight cap
+type limiter struct {
+	rate      *tokenBucket
+	inFlight  semaphore
+	endpoints map[string]*limiter
+}
+
+// ? Helper function to get the limiter of an endpoint, creating it if needed
+func (l *limiter) endpoint(name string) *limiter {
+	if l.endpoints == nil {
+		l.endpoints = make(map[string]*limiter)
+	}
+	if l.endpoints[name] == nil {
+		l.endpoints[name] = &limiter{}
+	}
+	return l.endpoints[name]
+}
+
+// ? Helper function to wait for the client-wide and endpoint limits. The returned function
+// releases the in-flight slots and must be called once the request completes.
+func (l *limiter) acquire(ctx context.Context, endpoint string) (func(), error) {
+	scopes := []*limiter{l}
+	if e := l.endpoints[endpoint]; e != nil {
+		scopes = append(scopes, e)
+	}
+
+	var releases []func()
+	var reserved []*tokenBucket
+	release := func() {
+		for _, r := range releases {
+			r()
+		}
+	}
+	//? Give the tokens back when a later scope doesn't let the request through
+	fail := func(err error) (func(), error) {
+		for _, bucket := range reserved {
+			bucket.cancel()
+		}
+		release()
+		return nil, err
+	}
+
+	//? Wait for every rate limit before taking any in-flight slot, so that a throttled
+	// endpoint doesn't hold a client-wide slot while it waits
+	for _, scope := range scopes {
+		if err := scope.rate.wait(ctx); err != nil {
+			return fail(err)
+		}
+		if scope.rate != nil {
+			reserved = append(reserved, scope.rate)
+		}
+	}
+	//? The endpoint slot is taken first, for the same reason
+	for i := len(scopes) - 1; i >= 0; i-- {
+		if err := scopes[i].inFlight.acquire(ctx); err != nil {
+			return fail(err)
+		}
+		releases = append(releases, scopes[i].inFlight.release)
+	}
+	return release, nil
+}
+
+// ? tokenBucket is a token-bucket rate limiter refilling at rate tokens per second
+type tokenBucket struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+// ? Helper function to create a token bucket, or nil when the rate is not positive
+func newTokenBucket(rps float64, burst int) *tokenBucket {
+	if rps <= 0 {
+		return nil
+	}
+	b := float64(max(burst, 1))
+	return &tokenBucket{rate: rps, burst: b, tokens: b, last: time.Now()}
+}
+
+// ? Helper function to take a token, waiting for one to be available if needed
+func (b *tokenBucket) wait(ctx context.Context) error {
+	if b == nil {
+		return nil
+	}
+
+	delay := b.reserve(time.Now())
+	if delay <= 0 {
+		return nil
+	}
+	if err := sleep(ctx, delay); err != nil {
+		b.cancel()
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return err
+	}
+	return nil
+}
+
+// ? Helper function to reserve a token at the given time, going into debt if none is available,
+// and to get how long to wait until the debt is paid off
+func (b *tokenBucket) reserve(now time.Time) time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if now.After(b.last) {
+		b.tokens = min(b.burst, b.tokens+now.Sub(b.last).Seconds()*b.rate)
+		b.last = now
+	}
+	b.tokens--
+	return max(time.Duration(-b.tokens/b.rate*float64(time.Second)), 0)
+}
+
+// ? Helper function to give a reserved token back, so that other callers don't wait for it
+func (b *tokenBucket) cancel() {
+	b.mu.Lock()
+	b.tokens = min(b.burst, b.tokens+1)
+	b.mu.Unlock()
+}
+
+// ? semaphore caps the number of concurrent holders; a nil semaphore never blocks
+type semaphore chan struct{}
+
+// ? Helper function to create a semaphore, or nil when n is not positive
+func newSemaphore(n int) semaphore {
+	if n <= 0 {
+		return nil
+	}
+	return make(semaphore, n)
+}
+
+func (s semaphore) acquire(ctx context.Context) error {
+	if s == nil {
+		return nil
+	}
+	select {
+	case s <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (s semaphore) release() {
+	if s != nil {
+		<-s
+	}
+}
@@ -0,0 +1,191 @@
+package greip_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	greip "github.com/greipio/go"
+	"github.com/greipio/go/greiptest"
+)
+
+func TestTokenBucketBurstThenThrottle(t *testing.T) {
+	bucket := greip.NewTokenBucket(10, 3)
+	//? Reserving in the future refills the bucket up to its burst, whatever the creation time
+	start := time.Now().Add(time.Second)
+
+	want := []time.Duration{0, 0, 0, 100 * time.Millisecond, 200 * time.Millisecond}
+	for i, w := range want {
+		if got := bucket.Reserve(start); got != w {
+			t.Fatalf("reservation %d: got delay %s, want %s", i+1, got, w)
+		}
+	}
+
+	//? 100ms later, one token has been refilled, paying off part of the debt
+	if got, want := bucket.Reserve(start.Add(100*time.Millisecond)), 200*time.Millisecond; got != want {
+		t.Fatalf("got delay %s, want %s", got, want)
+	}
+}
+
+func TestTokenBucketCancelReleasesReservation(t *testing.T) {
+	tests := []struct {
+		name    string
+		context func() (context.Context, context.CancelFunc)
+		err     error
+	}{
+		{"deadline", func() (context.Context, context.CancelFunc) {
+			return context.WithTimeout(context.Background(), 10*time.Millisecond)
+		}, context.DeadlineExceeded},
+		{"cancel", func() (context.Context, context.CancelFunc) {
+			ctx, cancel := context.WithCancel(context.Background())
+			time.AfterFunc(10*time.Millisecond, cancel)
+			return ctx, cancel
+		}, context.Canceled},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bucket := greip.NewTokenBucket(1, 1)
+			start := time.Now().Add(time.Hour)
+			bucket.Reserve(start)
+
+			ctx, cancel := tt.context()
+			defer cancel()
+			if err := bucket.Wait(ctx); !errors.Is(err, tt.err) {
+				t.Fatalf("got error %v, want %v", err, tt.err)
+			}
+
+			//? Without the cancelled reservation, the next caller only waits for one token
+			if got, want := bucket.Reserve(start), time.Second; got != want {
+				t.Fatalf("got delay %s, want %s", got, want)
+			}
+		})
+	}
+}
+
+func TestEndpointRateLimitStacksOnClientLimit(t *testing.T) {
+	client := greip.NewClient("token",
+		greip.WithRateLimit(0.001, 5),
+		greip.WithEndpointRateLimit("IPLookup", 0.001, 1),
+	)
+	acquire := func(endpoint string) error {
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+		release, err := client.AcquireLimits(ctx, endpoint)
+		if err == nil {
+			release()
+		}
+		return err
+	}
+
+	if err := acquire("IPLookup"); err != nil {
+		t.Fatalf("first IPLookup: %v", err)
+	}
+	if err := acquire("IPLookup"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("second IPLookup: got %v, want the endpoint limit to apply", err)
+	}
+
+	//? The client-wide burst of 5 is shared: one token went to IPLookup, and the one
+	// reserved by the rejected IPLookup was given back
+	for i := 0; i < 4; i++ {
+		if err := acquire("threats"); err != nil {
+			t.Fatalf("threats %d: %v", i+1, err)
+		}
+	}
+	if err := acquire("threats"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("sixth request: got %v, want the client-wide limit to apply", err)
+	}
+}
+
+func TestMaxConcurrency(t *testing.T) {
+	server := greiptest.NewServer()
+	defer server.Close()
+	server.SetLatency("IPLookup", 50*time.Millisecond)
+
+	var inFlight, peak atomic.Int32
+	counter := func(next greip.RoundTripFunc) greip.RoundTripFunc {
+		return func(call *greip.Call) (*http.Response, error) {
+			n := inFlight.Add(1)
+			defer inFlight.Add(-1)
+			for p := peak.Load(); n > p && !peak.CompareAndSwap(p, n); p = peak.Load() {
+			}
+			return next(call)
+		}
+	}
+	client := server.Client(greip.WithMaxConcurrency(2), greip.WithMiddleware(counter))
+
+	var wg sync.WaitGroup
+	for i := 1; i <= 6; i++ {
+		wg.Add(1)
+		go func(ip string) {
+			defer wg.Done()
+			if _, err := client.Lookup(ip, nil); err != nil {
+				t.Errorf("Lookup(%s): %v", ip, err)
+			}
+		}(fmt.Sprintf("1.1.1.%d", i))
+	}
+	wg.Wait()
+
+	if got := peak.Load(); got != 2 {
+		t.Fatalf("got %d requests in flight at most, want 2", got)
+	}
+	if got := len(server.RequestsTo("IPLookup")); got != 6 {
+		t.Fatalf("got %d requests, want 6", got)
+	}
+}
+
+func TestTokenBucketCancelKeepsBurst(t *testing.T) {
+	bucket := greip.NewTokenBucket(1, 2)
+	start := time.Now().Add(time.Hour)
+	bucket.Reserve(start)
+	for i := 0; i < 5; i++ {
+		bucket.Cancel()
+	}
+
+	//? Cancelling more than was reserved must not raise the burst above 2
+	want := []time.Duration{0, 0, time.Second}
+	for i, w := range want {
+		if got := bucket.Reserve(start); got != w {
+			t.Fatalf("reservation %d: got delay %s, want %s", i+1, got, w)
+		}
+	}
+}
+
+func TestThrottledEndpointDoesNotHoldClientSlot(t *testing.T) {
+	server := greiptest.NewServer()
+	defer server.Close()
+	client := server.Client(
+		greip.WithMaxConcurrency(1),
+		greip.WithEndpointRateLimit("paymentFraud", 0.5, 1),
+	)
+
+	payment := map[string]interface{}{"customer_id": "1"}
+	if _, err := client.Payment(payment); err != nil {
+		t.Fatalf("first Payment: %v", err)
+	}
+
+	//? The second payment waits about 2 seconds for its endpoint token
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	done := make(chan error, 1)
+	go func() {
+		_, err := client.PaymentContext(ctx, payment)
+		done <- err
+	}()
+	time.Sleep(50 * time.Millisecond)
+
+	lookupCtx, lookupCancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer lookupCancel()
+	if _, err := client.LookupContext(lookupCtx, "1.1.1.1", nil); err != nil {
+		t.Fatalf("Lookup blocked by the throttled endpoint: %v", err)
+	}
+
+	cancel()
+	if err := <-done; !errors.Is(err, context.Canceled) {
+		t.Fatalf("got error %v, want the waiting payment to be cancelled", err)
+	}
+}
@@ -0,0 +1,141 @@
+package greip
+
+import (
+	"context"
+	"errors"
+	"math"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// RetryPolicy controls how failed requests are retried. The zero value disables retries.
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts, including the first one.
+	// Values lower than 2 disable retries.
+	MaxAttempts int
+	// BaseBackoff is the delay before the first retry. It doubles after every attempt.
+	BaseBackoff time.Duration
+	// MaxBackoff caps the delay between two attempts. Zero means no practical cap: the delay
+	// stops doubling only before it would overflow.
+	// A Retry-After header asking for a longer delay stops the retries.
+	MaxBackoff time.Duration
+	// Jitter is the fraction (between 0 and 1) of every delay that is randomized,
+	// so that clients failing together don't retry together.
+	Jitter float64
+	// RetryableStatusCodes lists the HTTP status codes that trigger a retry.
+	// Network errors are always retried.
+	RetryableStatusCodes []int
+	// RetryNonIdempotent enables retries of non-idempotent requests, i.e. Payment.
+	// Only GET endpoints are retried by default, since retrying a payment check may count it twice.
+	RetryNonIdempotent bool
+}
+
+// DefaultRetryPolicy is a sensible retry policy to use with WithRetryPolicy.
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts: 3,
+	BaseBackoff: 200 * time.Millisecond,
+	MaxBackoff:  5 * time.Second,
+	Jitter:      0.2,
+	RetryableStatusCodes: []int{
+		http.StatusTooManyRequests,
+		http.StatusBadGateway,
+		http.StatusServiceUnavailable,
+		http.StatusGatewayTimeout,
+	},
+}
+
+// WithRetryPolicy enables retries of failed requests according to policy.
+// Retry-After headers sent with 429 and 503 responses take precedence over the backoff,
+// unless they exceed the MaxBackoff of the policy, in which case the request is not retried.
+func WithRetryPolicy(policy RetryPolicy) Option {
+	return func(g *Greip) {
+		g.retry = policy
+	}
+}
+
+// ? Helper function to get the number of attempts allowed for a request
+func (p RetryPolicy) attempts(idempotent bool) int {
+	if !idempotent && !p.RetryNonIdempotent {
+		return 1
+	}
+	return max(p.MaxAttempts, 1)
+}
+
+// ? Helper function to check whether a failed attempt should be retried
+func (p RetryPolicy) shouldRetry(ctx context.Context, resp *http.Response, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	if resp == nil {
+		return err != nil && !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded)
+	}
+	for _, code := range p.RetryableStatusCodes {
+		if resp.StatusCode == code {
+			return true
+		}
+	}
+	return false
+}
+
+// ? Longest delay computed by backoff: far beyond any practical wait, and small enough for the jitter not to overflow
+const maxDelay = time.Duration(math.MaxInt64 / 4)
+
+// ? Helper function to compute the delay before the next attempt. It returns false when the
+// server asks for a longer delay than MaxBackoff, in which case the request must not be retried.
+func (p RetryPolicy) backoff(attempt int, resp *http.Response) (time.Duration, bool) {
+	if wait, ok := retryAfter(resp); ok {
+		return wait, p.MaxBackoff <= 0 || wait <= p.MaxBackoff
+	}
+
+	//? Without MaxBackoff, stop doubling before the delay overflows into a negative duration
+	delay := p.BaseBackoff
+	for i := 1; i < attempt && (p.MaxBackoff <= 0 || delay < p.MaxBackoff) && delay <= maxDelay/2; i++ {
+		delay *= 2
+	}
+	delay = min(delay, maxDelay)
+	if p.MaxBackoff > 0 && delay > p.MaxBackoff {
+		delay = p.MaxBackoff
+	}
+
+	if jitter := min(max(p.Jitter, 0), 1); jitter > 0 && delay > 0 {
+		spread := time.Duration(float64(delay) * jitter)
+		delay = delay - spread + rand.N(2*spread+1)
+	}
+	return delay, true
+}
+
+// ? Helper function to read the Retry-After header of 429 and 503 responses, in seconds or as an HTTP date
+func retryAfter(resp *http.Response) (time.Duration, bool) {
+	if resp == nil || (resp.StatusCode != http.StatusTooManyRequests && resp.StatusCode != http.StatusServiceUnavailable) {
+		return 0, false
+	}
+	value := resp.Header.Get("Retry-After")
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		return max(time.Until(date), 0), true
+	}
+	return 0, false
+}
+
+// ? Helper function to wait for the given delay, giving up early if the context is done
+// or if its deadline would pass before the delay elapses
+func sleep(ctx context.Context, delay time.Duration) error {
+	if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < delay {
+		return context.DeadlineExceeded
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,92 @@
+package greip_test
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	greip "github.com/greipio/go"
+	"github.com/greipio/go/greiptest"
+)
+
+func TestRetryAfter(t *testing.T) {
+	tests := []struct {
+		name       string
+		retryAfter string
+		maxBackoff time.Duration
+		requests   int
+	}{
+		{"within MaxBackoff", "0", 5 * time.Second, 2},
+		{"beyond MaxBackoff", "3600", 5 * time.Second, 1},
+		{"HTTP date beyond MaxBackoff", time.Now().Add(time.Hour).UTC().Format(http.TimeFormat), 5 * time.Second, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := greiptest.NewServer()
+			defer server.Close()
+			server.Fail("IPLookup", greiptest.Failure{
+				StatusCode: http.StatusTooManyRequests,
+				Header:     http.Header{"Retry-After": {tt.retryAfter}},
+				Times:      1,
+			})
+
+			policy := greip.DefaultRetryPolicy
+			policy.MaxBackoff = tt.maxBackoff
+			client := server.Client(greip.WithRetryPolicy(policy))
+
+			start := time.Now()
+			_, err := client.Lookup("1.1.1.1", nil)
+			if elapsed := time.Since(start); elapsed > time.Second {
+				t.Fatalf("Lookup blocked for %s", elapsed)
+			}
+			if got := len(server.RequestsTo("IPLookup")); got != tt.requests {
+				t.Fatalf("got %d requests, want %d", got, tt.requests)
+			}
+			if tt.requests == 1 && !errors.Is(err, greip.ErrRateLimited) {
+				t.Fatalf("got error %v, want ErrRateLimited", err)
+			}
+			if tt.requests == 2 && err != nil {
+				t.Fatalf("got error %v after retry", err)
+			}
+		})
+	}
+}
+
+func TestRetryNonIdempotent(t *testing.T) {
+	server := greiptest.NewServer()
+	defer server.Close()
+	server.Fail("paymentFraud", greiptest.Failure{StatusCode: http.StatusServiceUnavailable, Times: 1})
+
+	policy := greip.DefaultRetryPolicy
+	policy.BaseBackoff = time.Millisecond
+	client := server.Client(greip.WithRetryPolicy(policy))
+
+	if _, err := client.Payment(map[string]interface{}{"customer_id": "1"}); err == nil {
+		t.Fatal("Payment was retried without RetryNonIdempotent")
+	}
+	if got := len(server.RequestsTo("paymentFraud")); got != 1 {
+		t.Fatalf("got %d requests, want 1", got)
+	}
+}
+
+func TestBackoffWithoutMaxBackoff(t *testing.T) {
+	for _, jitter := range []float64{0, 1} {
+		policy := greip.RetryPolicy{MaxAttempts: 200, BaseBackoff: time.Second, Jitter: jitter}
+		previous := time.Duration(0)
+		for attempt := 1; attempt < policy.MaxAttempts; attempt++ {
+			delay, ok := policy.Backoff(attempt)
+			if !ok || delay < 0 {
+				t.Fatalf("jitter %v, attempt %d: got delay %s, %v", jitter, attempt, delay, ok)
+			}
+			//? Without jitter, the delay keeps growing until it reaches its ceiling
+			if jitter == 0 && delay < previous {
+				t.Fatalf("attempt %d: delay went down from %s to %s", attempt, previous, delay)
+			}
+			previous = delay
+		}
+		if jitter == 0 && previous < 50*365*24*time.Hour {
+			t.Fatalf("got final delay %s, want it to have kept doubling", previous)
+		}
+	}
+}
@@ -0,0 +1,200 @@
+package greip
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ? Default delay after which an incomplete batch of streamed IP addresses is sent anyway
+const defaultStreamFlushInterval = 100 * time.Millisecond
+
+// WithStreamFlushInterval sets how long LookupStream waits for a batch of IP addresses
+// to fill up before sending it anyway.
+func WithStreamFlushInterval(interval time.Duration) Option {
+	return func(g *Greip) {
+		g.streamFlushInterval = interval
+	}
+}
+
+// LookupResult is the result of the lookup of a single IP address by LookupStream.
+type LookupResult struct {
+	// IP is the IP address as received on the input channel.
+	IP string
+	// Response is the lookup result, nil if Err is set.
+	Response *ResponseLookup
+	// Err is the reason why the IP address could not be looked up.
+	Err error
+}
+
+// LookupStream looks up the IP addresses received on in, as they arrive, and emits their results
+// on the returned channel as soon as they complete.
+//
+// The IP addresses are batched internally and sent to the BulkLookup endpoint, in chunks of
+// the size set by WithBulkChunkSize. A batch is sent once it is full or once the interval set by
+// WithStreamFlushInterval has elapsed, with at most WithBulkConcurrency batches in flight.
+// Batches go through the rate limits of the client, and reading from in pauses while the
+// client is at capacity.
+//
+// IP addresses are normalised before being sent, and invalid or non-routable ones are settled
+// without any request (see WithNonRoutableIPs). An IP address received again while it is already
+// waiting to be looked up is not looked up twice, and only one result is emitted for it.
+//
+// The returned channel is closed once in is closed and every result has been emitted, or once
+// ctx is done. Invalid params or lang are reported as a single result carrying a *ValidationError.
+//
+// Example usage:
+//
+//	results := greipInstance.LookupStream(ctx, ips, []string{"security"})
+//	for result := range results {
+//	    if result.Err != nil {
+//	        log.Printf("lookup of %s failed: %v", result.IP, result.Err)
+//	        continue
+//	    }
+//	    fmt.Println(result.IP, result.Response.CountryCode)
+//	}
+func (g *Greip) LookupStream(ctx context.Context, in <-chan string, params []string, lang ...string) <-chan LookupResult {
+	//? If the user provides a value for lang, use it; otherwise, default to "en".
+	langValue := "EN"
+	if len(lang) > 0 {
+		langValue = lang[0]
+	}
+
+	out := make(chan LookupResult)
+	go g.stream(ctx, in, out, params, strings.ToUpper(langValue))
+	return out
+}
+
+// ? Helper function to batch the incoming IP addresses and emit their results
+func (g *Greip) stream(ctx context.Context, in <-chan string, out chan<- LookupResult, params []string, lang string) {
+	defer close(out)
+
+	emit := func(result LookupResult) bool {
+		select {
+		case out <- result:
+			return true
+		case <-ctx.Done():
+			return false
+		}
+	}
+
+	//? Validate the params and the language once for the whole stream
+	if params == nil {
+		params = []string{}
+	}
+	if err := validateParams(params, availableGeoIPParams); err != nil {
+		emit(LookupResult{Err: err})
+		return
+	}
+	if err := validateLang(lang); err != nil {
+		emit(LookupResult{Err: err})
+		return
+	}
+
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	var mu sync.Mutex
+	pending := make(map[string]string)
+	slots := newSemaphore(g.bulkConcurrencyOrDefault())
+	size := g.bulkChunkSizeOrDefault()
+
+	interval := g.streamFlushInterval
+	if interval <= 0 {
+		interval = defaultStreamFlushInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	var batch []string
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		chunk := batch
+		batch = nil
+
+		//? Wait for a free slot, which also stops reading the input while at capacity
+		if err := slots.acquire(ctx); err != nil {
+			return
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer slots.release()
+
+			response, err := g.bulkLookupChunk(ctx, chunk, params, lang)
+			for _, ip := range chunk {
+				mu.Lock()
+				input := pending[ip]
+				delete(pending, ip)
+				mu.Unlock()
+
+				result := LookupResult{IP: input, Err: err}
+				if err == nil {
+					if lookup, ok := (*response)[ip]; ok {
+						result.Response = &lookup
+					} else {
+						result.Err = fmt.Errorf("no result returned for IP address: %s", ip)
+					}
+				}
+
+				if !emit(result) {
+					return
+				}
+			}
+		}()
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			flush()
+		case ip, ok := <-in:
+			if !ok {
+				flush()
+				return
+			}
+			//? Settle the invalid and non-routable IPs without any request
+			addr, err := normalizeIP("ip", ip)
+			if err != nil {
+				if !emit(LookupResult{IP: ip, Err: err}) {
+					return
+				}
+				continue
+			}
+			if handled, err := g.checkRoutable(addr); handled {
+				result := LookupResult{IP: ip, Err: err}
+				if err == nil {
+					response := privateNetworkLookup(addr)
+					result.Response = &response
+				}
+				if !emit(result) {
+					return
+				}
+				continue
+			}
+
+			//? Skip the IP addresses that are already waiting for their result
+			key := addr.String()
+			mu.Lock()
+			_, duplicate := pending[key]
+			if !duplicate {
+				pending[key] = ip
+			}
+			mu.Unlock()
+			if duplicate {
+				continue
+			}
+
+			batch = append(batch, key)
+			if len(batch) >= size {
+				flush()
+			}
+		}
+	}
+}
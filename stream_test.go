@@ -0,0 +1,119 @@
+package greip_test
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"testing"
+	"time"
+
+	greip "github.com/greipio/go"
+	"github.com/greipio/go/greiptest"
+)
+
+func TestLookupStreamDrainsOnClose(t *testing.T) {
+	server := greiptest.NewServer()
+	defer server.Close()
+	client := server.Client(greip.WithBulkChunkSize(10), greip.WithStreamFlushInterval(time.Hour))
+
+	in := make(chan string)
+	out := client.LookupStream(context.Background(), in, nil)
+	go func() {
+		defer close(in)
+		for i := 1; i <= 25; i++ {
+			in <- fmt.Sprintf("1.1.1.%d", i)
+		}
+	}()
+
+	seen := make(map[string]bool)
+	for result := range out {
+		if result.Err != nil || result.Response == nil || result.Response.IP != result.IP {
+			t.Fatalf("got result %+v", result)
+		}
+		seen[result.IP] = true
+	}
+	if len(seen) != 25 {
+		t.Fatalf("got %d results, want 25", len(seen))
+	}
+	if got := len(server.RequestsTo("BulkLookup")); got != 3 {
+		t.Fatalf("got %d requests, want 3 batches", got)
+	}
+}
+
+func TestLookupStreamDeduplicates(t *testing.T) {
+	server := greiptest.NewServer()
+	defer server.Close()
+	client := server.Client(greip.WithStreamFlushInterval(time.Hour))
+
+	in := make(chan string, 5)
+	for _, ip := range []string{"1.1.1.1", "2.2.2.2", "1.1.1.1", "::ffff:1.1.1.1", "2.2.2.2"} {
+		in <- ip
+	}
+	close(in)
+
+	var results []greip.LookupResult
+	for result := range client.LookupStream(context.Background(), in, nil) {
+		results = append(results, result)
+	}
+	if len(results) != 2 {
+		t.Fatalf("got %d results, want 2: %+v", len(results), results)
+	}
+	requests := server.RequestsTo("BulkLookup")
+	if len(requests) != 1 || requests[0].Query.Get("ips") != "1.1.1.1,2.2.2.2" {
+		t.Fatalf("got requests %+v, want a single one for 1.1.1.1,2.2.2.2", requests)
+	}
+}
+
+func TestLookupStreamFlushesOnTick(t *testing.T) {
+	server := greiptest.NewServer()
+	defer server.Close()
+	client := server.Client(greip.WithBulkChunkSize(100), greip.WithStreamFlushInterval(10*time.Millisecond))
+
+	in := make(chan string)
+	defer close(in)
+	out := client.LookupStream(context.Background(), in, nil)
+
+	//? The batch is far from full and in stays open: only the ticker can send it
+	in <- "1.1.1.1"
+	select {
+	case result := <-out:
+		if result.Err != nil || result.IP != "1.1.1.1" {
+			t.Fatalf("got result %+v", result)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("incomplete batch was not flushed")
+	}
+}
+
+func TestLookupStreamCancel(t *testing.T) {
+	before := runtime.NumGoroutine()
+
+	server := greiptest.NewServer()
+	server.SetLatency("BulkLookup", 10*time.Second)
+	client := server.Client(greip.WithBulkChunkSize(1), greip.WithBulkConcurrency(1))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	in := make(chan string)
+	out := client.LookupStream(ctx, in, nil)
+
+	//? One batch is in flight and the next one waits for a slot when ctx is cancelled
+	in <- "1.1.1.1"
+	in <- "1.1.1.2"
+	waitFor(t, func() bool { return len(server.RequestsTo("BulkLookup")) == 1 })
+	cancel()
+
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+		for range out {
+		}
+	}()
+	select {
+	case <-closed:
+	case <-time.After(2 * time.Second):
+		t.Fatal("out was not closed after ctx was cancelled")
+	}
+
+	server.Close()
+	waitFor(t, func() bool { return runtime.NumGoroutine() <= before })
+}
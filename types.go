@@ -1,10 +1,43 @@
 package greip
 
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
 // ? Greip represents the Greip client
 type Greip struct {
-	token   string
-	BaseURL string
-	test    bool
+	token      string
+	BaseURL    string
+	test       bool
+	userAgent  string
+	timeout    time.Duration
+	transport  http.RoundTripper
+	httpClient *http.Client
+	retry      RetryPolicy
+	limits     limiter
+	cache      Cache
+	cacheTTLs  map[string]time.Duration
+
+	noCoalescing bool
+	flights      flightGroup
+
+	bulkChunkSize       int
+	bulkConcurrency     int
+	streamFlushInterval time.Duration
+
+	middlewares  []Middleware
+	roundTripper RoundTripFunc
+
+	logger      *slog.Logger
+	logLevels   *[2]slog.Level
+	noRedaction bool
+	observers   []Observer
+	usage       usageTally
+
+	nonRoutable NonRoutablePolicy
 }
 
 type LookupASN struct {
@@ -107,7 +140,7 @@ type LookupBrowser struct {
 type ResponseLookup struct {
 	IP                 string         `json:"ip"`
 	IPType             string         `json:"ipType"`
-	IPNumber           int            `json:"IPNumber"`
+	IPNumber           json.Number    `json:"IPNumber"`
 	ContinentName      string         `json:"continentName"`
 	ContinentCode      string         `json:"continentCode"`
 	ContinentGeoNameID int            `json:"continentGeoNameID"`
@@ -124,6 +157,16 @@ type ResponseLookup struct {
 	Timezone           LookupTimezone `json:"timezone"`
 	Security           LookupSecurity `json:"security"`
 	Device             LookupDevice   `json:"device"`
+
+	// FromCache reports whether the response was served from the cache.
+	FromCache bool `json:"-"`
+
+	// NonRoutable reports whether the response was built locally for a non-routable IP address,
+	// see WithNonRoutableIPs.
+	NonRoutable bool `json:"-"`
+
+	// Meta holds the metadata returned along with the response.
+	Meta Meta `json:"-"`
 }
 
 type Threats struct {
@@ -138,6 +181,16 @@ type Threats struct {
 type ResponseThreats struct {
 	IP      string  `json:"ip"`
 	Threats Threats `json:"threats"`
+
+	// FromCache reports whether the response was served from the cache.
+	FromCache bool `json:"-"`
+
+	// NonRoutable reports whether the response was built locally for a non-routable IP address,
+	// see WithNonRoutableIPs.
+	NonRoutable bool `json:"-"`
+
+	// Meta holds the metadata returned along with the response.
+	Meta Meta `json:"-"`
 }
 
 type CountryCurrency struct {
@@ -163,6 +216,12 @@ type ResponseCountry struct {
 	ContinentName      string          `json:"continentName"`
 	ContinentCode      string          `json:"continentCode"`
 	ContinentGeoNameID int             `json:"continentGeoNameID"`
+
+	// FromCache reports whether the response was served from the cache.
+	FromCache bool `json:"-"`
+
+	// Meta holds the metadata returned along with the response.
+	Meta Meta `json:"-"`
 }
 
 type ResponseProfanity struct {
@@ -170,6 +229,9 @@ type ResponseProfanity struct {
 	TotalProfaneWords int    `json:"totalBadWords"`
 	RiskScore         int    `json:"riskScore"`
 	IsSafe            bool   `json:"isSafe"`
+
+	// Meta holds the metadata returned along with the response.
+	Meta Meta `json:"-"`
 }
 
 type ASNIPv4 struct {
@@ -193,6 +255,12 @@ type ResponseASN struct {
 	TotalIPs     int     `json:"totalIPs"`
 	IPv4         ASNIPv4 `json:"IPv4"`
 	IPv6         ASNIPv6 `json:"IPv6"`
+
+	// FromCache reports whether the response was served from the cache.
+	FromCache bool `json:"-"`
+
+	// Meta holds the metadata returned along with the response.
+	Meta Meta `json:"-"`
 }
 
 type ResponseEmail struct {
@@ -200,6 +268,9 @@ type ResponseEmail struct {
 	Reason  string `json:"reason"`
 	IsValid bool   `json:"isValid"`
 	Email   string `json:"email"`
+
+	// Meta holds the metadata returned along with the response.
+	Meta Meta `json:"-"`
 }
 
 type ResponsePhone struct {
@@ -208,6 +279,9 @@ type ResponsePhone struct {
 	IsValid     bool   `json:"isValid"`
 	Phone       string `json:"phone"`
 	CountryCode string `json:"countryCode"`
+
+	// Meta holds the metadata returned along with the response.
+	Meta Meta `json:"-"`
 }
 
 type IBANFormats struct {
@@ -249,6 +323,9 @@ type ResponseIBAN struct {
 	Formats IBANFormats `json:"formats"`
 	Country IBANCountry `json:"country"`
 	Bank    IBANBank    `json:"bank"`
+
+	// Meta holds the metadata returned along with the response.
+	Meta Meta `json:"-"`
 }
 
 type PaymentRule struct {
@@ -261,4 +338,7 @@ type ResponsePayment struct {
 	Rules              []PaymentRule `json:"rules"`
 	TotalRulesChecked  int           `json:"rulesChecked"`
 	TotalRulesDetected int           `json:"rulesDetected"`
+
+	// Meta holds the metadata returned along with the response.
+	Meta Meta `json:"-"`
 }